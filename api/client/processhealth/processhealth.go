@@ -16,6 +16,7 @@ package processhealth
 
 import (
 	"context"
+	"time"
 
 	"github.com/gravitational/trace"
 
@@ -56,6 +57,69 @@ func (c *Client) GetProcessHealth(ctx context.Context, name string) (*processhea
 	return rsp, nil
 }
 
+// WatchProcessHealths opens a stream that first yields every currently known
+// ProcessHealth, then yields a ProcessHealth each time one is created,
+// updated, or deleted, so callers don't have to poll ListProcessHealths to
+// notice changes. A deleted ProcessHealth arrives carrying only its name.
+func (c *Client) WatchProcessHealths(ctx context.Context) (*Watcher, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.grpcClient.WatchProcessHealths(ctx, &processhealthv1.WatchProcessHealthsRequest{})
+	if err != nil {
+		cancel()
+		return nil, trace.Wrap(err)
+	}
+
+	w := &Watcher{
+		stream:  stream,
+		cancel:  cancel,
+		eventsC: make(chan *processhealthv1.ProcessHealth),
+		doneC:   make(chan struct{}),
+	}
+	go w.relay()
+	return w, nil
+}
+
+// Watcher streams ProcessHealth updates from WatchProcessHealths.
+type Watcher struct {
+	stream  processhealthv1.ProcessHealthService_WatchProcessHealthsClient
+	cancel  context.CancelFunc
+	eventsC chan *processhealthv1.ProcessHealth
+	doneC   chan struct{}
+	err     error
+}
+
+func (w *Watcher) relay() {
+	defer close(w.doneC)
+	defer close(w.eventsC)
+	for {
+		resp, err := w.stream.Recv()
+		if err != nil {
+			w.err = trace.Wrap(err)
+			return
+		}
+		select {
+		case w.eventsC <- resp.ProcessHealth:
+		case <-w.stream.Context().Done():
+			return
+		}
+	}
+}
+
+// Events returns the channel of ProcessHealth updates.
+func (w *Watcher) Events() <-chan *processhealthv1.ProcessHealth { return w.eventsC }
+
+// Done is closed once the watcher has stopped.
+func (w *Watcher) Done() <-chan struct{} { return w.doneC }
+
+// Error returns the error, if any, that stopped the watcher.
+func (w *Watcher) Error() error { return w.err }
+
+// Close stops the watcher and releases its resources.
+func (w *Watcher) Close() error {
+	w.cancel()
+	return nil
+}
+
 // UpsertProcessHealth upserts a Process Health.
 func (c *Client) UpsertProcessHealth(ctx context.Context, req *processhealthv1.ProcessHealth) (*processhealthv1.ProcessHealth, error) {
 	rsp, err := c.grpcClient.UpsertProcessHealth(ctx, &processhealthv1.UpsertProcessHealthRequest{
@@ -66,3 +130,19 @@ func (c *Client) UpsertProcessHealth(ctx context.Context, req *processhealthv1.P
 	}
 	return rsp, nil
 }
+
+// CaptureProfile captures a pprof profile from the process behind host (or
+// the auth service itself if host is empty), blocking for duration before
+// returning for profile types that sample over a window (e.g. "cpu").
+// profile must be one of "cpu", "heap", or "goroutine".
+func (c *Client) CaptureProfile(ctx context.Context, host, profile string, duration time.Duration) ([]byte, error) {
+	rsp, err := c.grpcClient.CaptureProcessHealthProfile(ctx, &processhealthv1.CaptureProcessHealthProfileRequest{
+		Host:            host,
+		Profile:         profile,
+		DurationSeconds: int64(duration.Seconds()),
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rsp.GetData(), nil
+}
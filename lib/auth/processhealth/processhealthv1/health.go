@@ -0,0 +1,126 @@
+/*
+ * Teleport
+ * Copyright (C) 2025  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package processhealthv1
+
+import (
+	"context"
+
+	"github.com/gravitational/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
+
+	processhealthv1 "github.com/gravitational/teleport/api/gen/proto/go/teleport/processhealth/v1"
+	"github.com/gravitational/teleport/api/types"
+)
+
+// RegisterHealthServer creates a standard grpc.health.v1.Health server
+// backed by s's ProcessHealth cache and registers it with grpcServer, so
+// load balancers, sidecars, and generic tooling like grpc_health_probe can
+// check Teleport process health without speaking the ProcessHealth API.
+//
+// The empty service name reports overall health: SERVING while every
+// tracked ProcessHealth is healthy, NOT_SERVING otherwise. A service name
+// equal to a ProcessHealth's Name reports that record's own SERVING or
+// NOT_SERVING status; it reports SERVICE_UNKNOWN once that record is
+// deleted.
+//
+// RegisterHealthServer starts a background goroutine that keeps the health
+// server in sync until ctx is done.
+func (s *Service) RegisterHealthServer(ctx context.Context, grpcServer *grpc.Server) error {
+	healthServer := health.NewServer()
+	healthv1.RegisterHealthServer(grpcServer, healthServer)
+
+	watcher, err := s.cache.WatchProcessHealths(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	initial, _, err := s.cache.ListProcessHealths(ctx, 0, "")
+	if err != nil {
+		watcher.Close()
+		return trace.Wrap(err)
+	}
+
+	healthy := make(map[string]bool, len(initial))
+	for _, ph := range initial {
+		name := ph.GetMetadata().GetName()
+		healthy[name] = isProcessHealthHealthy(ph)
+		healthServer.SetServingStatus(name, processHealthServingStatus(healthy[name]))
+	}
+	healthServer.SetServingStatus("", overallServingStatus(healthy))
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event, ok := <-watcher.Events():
+				if !ok {
+					return
+				}
+				name := event.Resource.GetMetadata().GetName()
+				if event.Type == types.OpDelete {
+					delete(healthy, name)
+					healthServer.SetServingStatus(name, healthv1.HealthCheckResponse_SERVICE_UNKNOWN)
+				} else {
+					healthy[name] = isProcessHealthHealthy(event.Resource)
+					healthServer.SetServingStatus(name, processHealthServingStatus(healthy[name]))
+				}
+				healthServer.SetServingStatus("", overallServingStatus(healthy))
+			case <-watcher.Done():
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// isProcessHealthHealthy reports whether every unit in ph is reporting an
+// "ok" state.
+func isProcessHealthHealthy(ph *processhealthv1.ProcessHealth) bool {
+	for _, unit := range ph.GetStatus().GetUnitsByName() {
+		if unit.GetState() != "ok" {
+			return false
+		}
+	}
+	return true
+}
+
+// overallServingStatus reports SERVING only while every tracked
+// ProcessHealth is healthy.
+func overallServingStatus(healthy map[string]bool) healthv1.HealthCheckResponse_ServingStatus {
+	for _, ok := range healthy {
+		if !ok {
+			return healthv1.HealthCheckResponse_NOT_SERVING
+		}
+	}
+	return healthv1.HealthCheckResponse_SERVING
+}
+
+// processHealthServingStatus maps a single ProcessHealth's health to the
+// standard gRPC health serving status.
+func processHealthServingStatus(healthy bool) healthv1.HealthCheckResponse_ServingStatus {
+	if healthy {
+		return healthv1.HealthCheckResponse_SERVING
+	}
+	return healthv1.HealthCheckResponse_NOT_SERVING
+}
@@ -19,15 +19,23 @@
 package processhealthv1
 
 import (
+	"bytes"
 	"context"
+	"log/slog"
+	"runtime"
+	"runtime/pprof"
+	"time"
 
 	"github.com/gravitational/trace"
 	"github.com/jonboulle/clockwork"
 
+	"github.com/gravitational/teleport"
 	processhealthv1 "github.com/gravitational/teleport/api/gen/proto/go/teleport/processhealth/v1"
 	"github.com/gravitational/teleport/api/types"
 	apievents "github.com/gravitational/teleport/api/types/events"
 	"github.com/gravitational/teleport/lib/authz"
+	"github.com/gravitational/teleport/lib/events"
+	processhealthmetrics "github.com/gravitational/teleport/lib/observability/metrics/processhealth"
 	"github.com/gravitational/teleport/lib/services"
 	usagereporter "github.com/gravitational/teleport/lib/usagereporter/teleport"
 )
@@ -51,6 +59,14 @@ type ServiceConfig struct {
 
 	// Emitter is the event emitter.
 	Emitter apievents.Emitter
+
+	// AuditChanges enables emitting a ProcessHealthStatusChanged audit event
+	// whenever UpsertProcessHealth observes a status transition. Metrics are
+	// always reported regardless of this setting.
+	AuditChanges bool
+
+	// Logger is the slog.Logger.
+	Logger *slog.Logger
 }
 
 // CheckAndSetDefaults checks the ServiceConfig fields and returns an error if
@@ -75,6 +91,9 @@ func (s *ServiceConfig) CheckAndSetDefaults() error {
 	if s.Clock == nil {
 		s.Clock = clockwork.NewRealClock()
 	}
+	if s.Logger == nil {
+		s.Logger = slog.With(teleport.ComponentKey, "process_health")
+	}
 
 	return nil
 }
@@ -83,6 +102,7 @@ func (s *ServiceConfig) CheckAndSetDefaults() error {
 type Reader interface {
 	ListProcessHealths(ctx context.Context, pageSize int64, nextToken string) ([]*processhealthv1.ProcessHealth, string, error)
 	GetProcessHealth(ctx context.Context, name string) (*processhealthv1.ProcessHealth, error)
+	WatchProcessHealths(ctx context.Context) (services.ProcessHealthWatcher, error)
 }
 
 // Service implements the teleport.ProcessHealth.v1.ProcessHealthService RPC service.
@@ -95,6 +115,8 @@ type Service struct {
 	clock         clockwork.Clock
 	usageReporter func() usagereporter.UsageReporter
 	emitter       apievents.Emitter
+	auditChanges  bool
+	logger        *slog.Logger
 }
 
 // NewService returns a new ProcessHealth gRPC service.
@@ -102,6 +124,9 @@ func NewService(cfg ServiceConfig) (*Service, error) {
 	if err := cfg.CheckAndSetDefaults(); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := processhealthmetrics.Register(); err != nil {
+		return nil, trace.Wrap(err)
+	}
 
 	return &Service{
 		authorizer:    cfg.Authorizer,
@@ -110,6 +135,8 @@ func NewService(cfg ServiceConfig) (*Service, error) {
 		clock:         cfg.Clock,
 		usageReporter: cfg.UsageReporter,
 		emitter:       cfg.Emitter,
+		auditChanges:  cfg.AuditChanges,
+		logger:        cfg.Logger,
 	}, nil
 }
 
@@ -155,6 +182,72 @@ func (s *Service) GetProcessHealth(ctx context.Context, req *processhealthv1.Get
 
 }
 
+// WatchProcessHealths streams ProcessHealth resources to the caller as they
+// change, so tools like `tctl health` don't have to poll. The stream opens
+// with a snapshot of every currently known ProcessHealth, followed by the
+// affected ProcessHealth for each create, update, or delete as it happens.
+// A deleted ProcessHealth is sent carrying only its name; the response does
+// not say which kind of change it reports.
+func (s *Service) WatchProcessHealths(req *processhealthv1.WatchProcessHealthsRequest, stream processhealthv1.ProcessHealthService_WatchProcessHealthsServer) error {
+	ctx := stream.Context()
+	authCtx, err := s.authorizer.Authorize(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	if err := authCtx.CheckAccessToKind(types.KindProcessHealth, types.VerbRead, types.VerbList); err != nil {
+		return trace.Wrap(err)
+	}
+
+	watcher, err := s.cache.WatchProcessHealths(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer watcher.Close()
+
+	if err := s.sendProcessHealthSnapshot(ctx, stream); err != nil {
+		return trace.Wrap(err)
+	}
+
+	for {
+		select {
+		case event, ok := <-watcher.Events():
+			if !ok {
+				return trace.Wrap(watcher.Error())
+			}
+			if err := stream.Send(&processhealthv1.WatchProcessHealthsResponse{ProcessHealth: event.Resource}); err != nil {
+				return trace.Wrap(err)
+			}
+		case <-watcher.Done():
+			return trace.Wrap(watcher.Error())
+		case <-ctx.Done():
+			return trace.Wrap(ctx.Err())
+		}
+	}
+}
+
+// sendProcessHealthSnapshot streams every currently known ProcessHealth
+// before the incremental watch loop begins, so callers get a complete view
+// of current state without a separate ListProcessHealths round trip.
+func (s *Service) sendProcessHealthSnapshot(ctx context.Context, stream processhealthv1.ProcessHealthService_WatchProcessHealthsServer) error {
+	var pageToken string
+	for {
+		page, nextToken, err := s.cache.ListProcessHealths(ctx, 0, pageToken)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		for _, ph := range page {
+			if err := stream.Send(&processhealthv1.WatchProcessHealthsResponse{ProcessHealth: ph}); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		if nextToken == "" {
+			return nil
+		}
+		pageToken = nextToken
+	}
+}
+
 // UpsertProcessHealth upserts user task resource.
 func (s *Service) UpsertProcessHealth(ctx context.Context, req *processhealthv1.UpsertProcessHealthRequest) (*processhealthv1.ProcessHealth, error) {
 	authCtx, err := s.authorizer.Authorize(ctx)
@@ -166,10 +259,123 @@ func (s *Service) UpsertProcessHealth(ctx context.Context, req *processhealthv1.
 		return nil, trace.Wrap(err)
 	}
 
+	previous, err := s.cache.GetProcessHealth(ctx, req.GetProcessHealth().GetMetadata().GetName())
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+
 	rsp, err := s.backend.UpsertProcessHealth(ctx, req.ProcessHealth)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
+	s.reportStatusChange(ctx, authCtx, previous, rsp)
+
 	return rsp, nil
 }
+
+// processHealthStatusLabel returns the Prometheus/audit status label for ph:
+// "healthy" if every unit is reporting "ok", "degraded" otherwise.
+func processHealthStatusLabel(ph *processhealthv1.ProcessHealth) string {
+	if isProcessHealthHealthy(ph) {
+		return "healthy"
+	}
+	return "degraded"
+}
+
+// reportStatusChange diffs previous (the cached record before this upsert,
+// nil if this is a new process) against updated, updates the processhealth
+// metrics, and - if the status actually changed and AuditChanges is set -
+// emits a ProcessHealthStatusChanged audit event.
+func (s *Service) reportStatusChange(ctx context.Context, authCtx *authz.Context, previous, updated *processhealthv1.ProcessHealth) {
+	name := updated.GetMetadata().GetName()
+	newStatus := processHealthStatusLabel(updated)
+	oldStatus := "unknown"
+	if previous != nil {
+		oldStatus = processHealthStatusLabel(previous)
+	}
+
+	processhealthmetrics.StatusGauge.WithLabelValues(name, "healthy").Set(boolToFloat(newStatus == "healthy"))
+	processhealthmetrics.StatusGauge.WithLabelValues(name, "degraded").Set(boolToFloat(newStatus == "degraded"))
+
+	if oldStatus == newStatus {
+		return
+	}
+	processhealthmetrics.TransitionsTotal.WithLabelValues(name, newStatus).Inc()
+
+	if !s.auditChanges {
+		return
+	}
+	if err := s.emitter.EmitAuditEvent(ctx, &apievents.ProcessHealthStatusChanged{
+		Metadata: apievents.Metadata{
+			Type: events.ProcessHealthStatusChangedEvent,
+			Code: events.ProcessHealthStatusChangedCode,
+		},
+		UserMetadata: authCtx.Identity.GetIdentity().GetUserMetadata(),
+		ResourceMetadata: apievents.ResourceMetadata{
+			Name: name,
+		},
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+	}); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to emit ProcessHealthStatusChanged audit event", "error", err)
+	}
+}
+
+// boolToFloat converts b to a Prometheus gauge value.
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// CaptureProcessHealthProfile captures a pprof profile and returns its
+// encoded bytes, for operators diagnosing a process flagged unhealthy by
+// `tctl health`.
+//
+// req.Host is not yet supported: routing a profile request to an arbitrary
+// remote host would require a profiling RPC on that host's own process,
+// which doesn't exist yet. Rather than silently profiling the local auth
+// process and handing back results that look like they came from the
+// requested host, a non-empty Host is rejected outright until per-host
+// routing is implemented.
+func (s *Service) CaptureProcessHealthProfile(ctx context.Context, req *processhealthv1.CaptureProcessHealthProfileRequest) (*processhealthv1.CaptureProcessHealthProfileResponse, error) {
+	if _, err := s.authorizer.Authorize(ctx); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if req.GetHost() != "" {
+		return nil, trace.NotImplemented("profiling a specific host is not yet supported, got host %q", req.GetHost())
+	}
+
+	var buf bytes.Buffer
+	switch req.GetProfile() {
+	case "cpu":
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		duration := time.Duration(req.GetDurationSeconds()) * time.Second
+		if duration <= 0 {
+			duration = 30 * time.Second
+		}
+		select {
+		case <-time.After(duration):
+		case <-ctx.Done():
+		}
+		pprof.StopCPUProfile()
+	case "heap":
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(&buf); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	case "goroutine":
+		if err := pprof.Lookup("goroutine").WriteTo(&buf, 0); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	default:
+		return nil, trace.BadParameter("unsupported profile %q, must be one of cpu, heap, goroutine", req.GetProfile())
+	}
+
+	return &processhealthv1.CaptureProcessHealthProfileResponse{Data: buf.Bytes()}, nil
+}
@@ -20,41 +20,412 @@ package windows
 
 import (
 	"context"
-	"log"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
 	"net"
+	"strings"
+	"time"
 
+	"github.com/go-asn1-ber/asn1-ber"
 	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport"
+)
+
+var log = slog.With(teleport.ComponentKey, "windows_desktop")
+
+// cldapPingTimeout bounds how long we wait for a single CLDAP netlogon ping
+// response before giving up on a candidate DC.
+const cldapPingTimeout = 2 * time.Second
+
+// cldapOpLDAPSearchRequest and cldapOpLDAPSearchResponse are the CLDAP/LDAP
+// protocol op tags used to build and parse the netlogon ping.
+const (
+	cldapOpLDAPSearchRequest  ber.Tag = 3 // [APPLICATION 3] SearchRequest
+	cldapOpLDAPSearchResponse ber.Tag = 4 // [APPLICATION 4] SearchResultEntry
 )
 
-// LocateLDAPServer looks up the LDAP server in an Active Directory
-// environment by implementing the DNS-based discovery DC locator
-// process.
+// ldapNetlogonOpcode is LDAP_NETLOGON (opcode 23), used as a CLDAP-over-UDP
+// "ping" to find out whether a DC is alive without doing a full LDAP bind.
+// See https://learn.microsoft.com/en-us/openspecs/windows_protocols/ms-adts/8b2e2518-c57e-4c1b-9e3c-f5f3f9d66d43
+const ldapNetlogonOpcode = 23
+
+// DiscoveredDC describes a domain controller discovered via DNS-based
+// discovery (the DC locator process), optionally confirmed reachable via a
+// CLDAP netlogon ping.
+type DiscoveredDC struct {
+	// Host is the resolved IP address or hostname of the domain controller.
+	Host string
+	// Port is the LDAP port advertised by the SRV record.
+	Port int
+	// Site is the AD site the DC was discovered in, if known.
+	Site string
+	// DomainGUID is the domain GUID reported by the DC's netlogon response,
+	// if a CLDAP ping was performed and the DC responded with one.
+	DomainGUID string
+}
+
+// Addr formats the discovered DC as a "host:port" string suitable for dialing.
+func (d DiscoveredDC) Addr() string {
+	return net.JoinHostPort(d.Host, fmt.Sprintf("%d", d.Port))
+}
+
+// LocateLDAPServer looks up the LDAP server(s) in an Active Directory
+// environment by implementing the DNS-based discovery DC locator process.
+//
+// Queries are attempted, in order, against the site-specific SRV record (if
+// site is non-empty or can be discovered via an initial CLDAP query), the
+// site-less _msdcs SRV record, and finally the domain-wide SRV record. SRV
+// targets are expanded to all of their resolved addresses, in SRV
+// priority/weight order, using the port advertised by the SRV record.
+//
+// If pingDCs is true, each candidate DC is probed with a CLDAP netlogon ping
+// (UDP 389, opcode 23); if at least one candidate responds, unresponsive DCs
+// are dropped from the result. Environments that block UDP 389 between
+// Teleport and the DCs (common when only LDAPS/636 is open between network
+// segments) would otherwise see every candidate dropped, so if pingDCs is
+// true but none of the candidates for a query respond, the unpinged
+// candidates are returned instead of an empty result.
 //
 // See https://learn.microsoft.com/en-us/windows-server/identity/ad-ds/manage/dc-locator?tabs=dns-based-discovery
-func LocateLDAPServer(ctx context.Context, domain string, resolver *net.Resolver) ([]string, error) {
-	log.Printf("DEBUG: Looking up SRV records for _ldap._tcp.%s", domain)
-	_, records, err := resolver.LookupSRV(ctx, "ldap", "tcp", domain)
+func LocateLDAPServer(ctx context.Context, domain string, site string, resolver *net.Resolver, pingDCs bool) ([]DiscoveredDC, error) {
+	if site == "" {
+		if discoveredSite, err := discoverSite(ctx, domain, resolver); err == nil {
+			site = discoveredSite
+		} else {
+			log.DebugContext(ctx, "Could not discover AD site, continuing without one", "error", err)
+		}
+	}
+
+	var lastErr error
+	for _, query := range srvQueries(domain, site) {
+		dcs, err := locateViaSRV(ctx, query.name, query.site, resolver, pingDCs)
+		if err != nil {
+			log.DebugContext(ctx, "SRV lookup failed", "query", query.name, "error", err)
+			lastErr = err
+			continue
+		}
+		if len(dcs) == 0 {
+			continue
+		}
+		return dcs, nil
+	}
+
+	if lastErr != nil {
+		return nil, trace.Wrap(lastErr, "locating LDAP server for domain %v", domain)
+	}
+	return nil, trace.NotFound("no SRV records found for domain %v", domain)
+}
+
+type srvQuery struct {
+	name string
+	site string
+}
+
+// srvQueries returns the DC locator SRV queries to try, in priority order:
+// site-aware, then _msdcs without a site, then the plain domain-wide record.
+func srvQueries(domain, site string) []srvQuery {
+	var queries []srvQuery
+	if site != "" {
+		queries = append(queries, srvQuery{
+			name: fmt.Sprintf("_ldap._tcp.%s._sites.dc._msdcs.%s", site, domain),
+			site: site,
+		})
+	}
+	queries = append(queries,
+		srvQuery{name: fmt.Sprintf("_ldap._tcp.dc._msdcs.%s", domain)},
+		srvQuery{name: fmt.Sprintf("_ldap._tcp.%s", domain)},
+	)
+	return queries
+}
+
+// locateViaSRV resolves a single SRV query to a list of candidate DCs. If
+// pingDCs is true, each candidate is pinged and unresponsive ones are
+// dropped, unless that would drop every candidate, in which case the
+// unpinged list is returned instead.
+func locateViaSRV(ctx context.Context, srvName, site string, resolver *net.Resolver, pingDCs bool) ([]DiscoveredDC, error) {
+	_, records, err := resolver.LookupSRV(ctx, "", "", srvName)
 	if err != nil {
-		log.Printf("DEBUG: Error looking up SRV records for %v: %v", domain, err)
-		return nil, trace.Wrap(err, "looking up SRV records for %v", domain)
+		return nil, trace.Wrap(err, "looking up SRV record %v", srvName)
+	}
+
+	// LookupSRV already returns records sorted by priority and shuffled by
+	// weight within each priority, so we expand targets in the order given.
+	var candidates []DiscoveredDC
+	for _, record := range records {
+		addrs, err := resolver.LookupHost(ctx, record.Target)
+		if err != nil {
+			log.DebugContext(ctx, "Failed to resolve SRV target", "target", record.Target, "error", err)
+			continue
+		}
+		for _, addr := range addrs {
+			candidates = append(candidates, DiscoveredDC{
+				Host: addr,
+				Port: int(record.Port),
+				Site: site,
+			})
+		}
+	}
+
+	if !pingDCs {
+		return candidates, nil
+	}
+
+	var responsive []DiscoveredDC
+	for _, dc := range candidates {
+		if guid, ok := pingDC(ctx, dc); ok {
+			dc.DomainGUID = guid
+			responsive = append(responsive, dc)
+		}
+	}
+	if len(responsive) == 0 && len(candidates) > 0 {
+		log.DebugContext(ctx, "No candidate DC responded to CLDAP ping, falling back to unpinged results", "query", srvName)
+		return candidates, nil
 	}
-	log.Printf("DEBUG: Found SRV records: %+v", records)
+	return responsive, nil
+}
 
-	// note: LookupSRV already returns records sorted by priority and takes in to account weights
-	result := make([]string, 0, len(records))
+// discoverSite issues a CLDAP netlogon ping against the domain-wide SRV
+// record to discover the AD site name of the closest DC, when the caller did
+// not supply one.
+func discoverSite(ctx context.Context, domain string, resolver *net.Resolver) (string, error) {
+	_, records, err := resolver.LookupSRV(ctx, "ldap", "tcp", domain)
+	if err != nil {
+		return "", trace.Wrap(err, "looking up SRV records for %v", domain)
+	}
 	for _, record := range records {
-		log.Printf("DEBUG: Looking up host for SRV record target: %s", record.Target)
 		addrs, err := resolver.LookupHost(ctx, record.Target)
 		if err != nil {
-			log.Printf("DEBUG: Error looking up host for %v: %v", record.Target, err)
 			continue
 		}
-		log.Printf("DEBUG: Found host addresses for %s: %v", record.Target, addrs)
-		if len(addrs) > 0 {
-			result = append(result, net.JoinHostPort(addrs[0], "636"))
+		for _, addr := range addrs {
+			site, err := cldapSitePing(ctx, net.JoinHostPort(addr, fmt.Sprintf("%d", record.Port)))
+			if err == nil && site != "" {
+				return site, nil
+			}
+		}
+	}
+	return "", trace.NotFound("could not discover AD site for domain %v", domain)
+}
+
+// netlogonPingResult holds the fields we care about out of a
+// NETLOGON_SAM_LOGON_RESPONSE_EX payload.
+type netlogonPingResult struct {
+	domainGUID string
+	site       string
+}
+
+// pingDC performs a CLDAP netlogon ping against dc, returning the domain GUID
+// and site name reported by the DC, and whether the DC responded at all.
+func pingDC(ctx context.Context, dc DiscoveredDC) (domainGUID string, responded bool) {
+	result, err := cldapPing(ctx, dc.Addr())
+	if err != nil {
+		log.DebugContext(ctx, "CLDAP netlogon ping failed", "host", dc.Addr(), "error", err)
+		return "", false
+	}
+	return result.domainGUID, true
+}
+
+// cldapSitePing issues a CLDAP netlogon ping and returns just the site name
+// reported by the DC at addr.
+func cldapSitePing(ctx context.Context, addr string) (string, error) {
+	result, err := cldapPing(ctx, addr)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return result.site, nil
+}
+
+// cldapPing sends a CLDAP netlogon ping (UDP 389, opcode 23) to addr and
+// parses the netlogon response.
+func cldapPing(ctx context.Context, addr string) (netlogonPingResult, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return netlogonPingResult{}, trace.Wrap(err, "dialing %v", addr)
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > cldapPingTimeout {
+		deadline = time.Now().Add(cldapPingTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return netlogonPingResult{}, trace.Wrap(err)
+	}
+
+	if _, err := conn.Write(encodeNetlogonPingRequest()); err != nil {
+		return netlogonPingResult{}, trace.Wrap(err, "sending netlogon ping")
+	}
+
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return netlogonPingResult{}, trace.Wrap(err, "reading netlogon ping response")
+	}
+
+	return decodeNetlogonPingResponse(buf[:n])
+}
+
+// encodeNetlogonPingRequest builds the raw bytes of a CLDAP search request
+// for the Netlogon attribute, filtered by NtVer, per MS-ADTS 7.3.3.
+func encodeNetlogonPingRequest() []byte {
+	msg := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Message")
+	msg.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, 1, "Message ID"))
+
+	searchRequest := ber.Encode(ber.ClassApplication, ber.TypeConstructed, cldapOpLDAPSearchRequest, nil, "Search Request")
+	searchRequest.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Base Object"))
+	searchRequest.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, 0, "Scope"))
+	searchRequest.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, 0, "Deref Aliases"))
+	searchRequest.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, 0, "Size Limit"))
+	searchRequest.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, 0, "Time Limit"))
+	searchRequest.AppendChild(ber.NewBoolean(ber.ClassUniversal, ber.TypePrimitive, ber.TagBoolean, false, "Types Only"))
+
+	ntVer := make([]byte, 4)
+	binary.LittleEndian.PutUint32(ntVer, 0x00000006) // NETLOGON_NT_VERSION_5EX
+	filter := ber.Encode(ber.ClassContext, ber.TypeConstructed, 3, nil, "Equality Match Filter")
+	filter.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "NtVer", "Attribute"))
+	filter.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, string(ntVer), "Value"))
+	searchRequest.AppendChild(filter)
+
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	attrs.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "Netlogon", "Attribute"))
+	searchRequest.AppendChild(attrs)
+
+	msg.AppendChild(searchRequest)
+	return msg.Bytes()
+}
+
+// decodeNetlogonPingResponse parses the netlogon response embedded in a
+// CLDAP search result entry, returning the domain GUID and AD site name.
+func decodeNetlogonPingResponse(data []byte) (netlogonPingResult, error) {
+	packet, err := ber.DecodePacketErr(data)
+	if err != nil {
+		return netlogonPingResult{}, trace.Wrap(err, "decoding CLDAP response")
+	}
+	if len(packet.Children) < 2 {
+		return netlogonPingResult{}, trace.BadParameter("malformed CLDAP response: missing protocol op")
+	}
+
+	entry := packet.Children[1]
+	if ber.Tag(entry.Tag) != cldapOpLDAPSearchResponse {
+		return netlogonPingResult{}, trace.BadParameter("unexpected CLDAP response op %v", entry.Tag)
+	}
+	if len(entry.Children) < 2 {
+		return netlogonPingResult{}, trace.BadParameter("malformed search result entry")
+	}
+
+	for _, attr := range entry.Children[1].Children {
+		if len(attr.Children) < 2 || attr.Children[0].Value != "Netlogon" {
+			continue
+		}
+		values := attr.Children[1].Children
+		if len(values) == 0 {
+			continue
+		}
+		payload := values[0].Data.Bytes()
+		return parseNetlogonSamLogonResponseEx(payload)
+	}
+	return netlogonPingResult{}, trace.NotFound("no Netlogon attribute in CLDAP response")
+}
+
+// parseNetlogonSamLogonResponseEx parses the NETLOGON_SAM_LOGON_RESPONSE_EX
+// structure returned by a CLDAP netlogon ping (opcode 23), per MS-ADTS
+// 7.3.1.9. We only need the fixed-offset DomainGuid field and the
+// compressed-name DcSiteName field, so we stop parsing once we reach it.
+func parseNetlogonSamLogonResponseEx(payload []byte) (netlogonPingResult, error) {
+	// Opcode (2 bytes) + Sbz (2 bytes) + Flags (4 bytes) precede DomainGuid.
+	const domainGUIDOffset = 8
+	const domainGUIDLen = 16
+	if len(payload) < domainGUIDOffset+domainGUIDLen {
+		return netlogonPingResult{}, trace.BadParameter("netlogon payload too short")
+	}
+
+	opcode := binary.LittleEndian.Uint16(payload[:2])
+	if opcode != ldapNetlogonOpcode {
+		return netlogonPingResult{}, trace.BadParameter("unexpected netlogon opcode %v", opcode)
+	}
+
+	result := netlogonPingResult{
+		domainGUID: formatGUID(payload[domainGUIDOffset : domainGUIDOffset+domainGUIDLen]),
+	}
+
+	// DnsForestName, DnsDomainName, DnsHostName, NetbiosDomainName,
+	// NetbiosComputerName, and UserName all precede DcSiteName; skip over
+	// them in order.
+	offset := domainGUIDOffset + domainGUIDLen
+	for i := 0; i < 6; i++ {
+		_, next, err := readCompressedName(payload, offset)
+		if err != nil {
+			// Older/shorter responses may not carry all of these fields; the
+			// domain GUID is still useful on its own.
+			return result, nil
 		}
+		offset = next
 	}
 
-	log.Printf("DEBUG: Final LDAP server addresses: %v", result)
+	site, _, err := readCompressedName(payload, offset)
+	if err != nil {
+		return result, nil
+	}
+	result.site = site
 	return result, nil
 }
+
+// formatGUID formats a little-endian 16-byte GUID as returned by Windows
+// (the first three fields are little-endian, the last two are big-endian),
+// as a standard "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx" string.
+func formatGUID(b []byte) string {
+	return fmt.Sprintf("%08x-%04x-%04x-%04x-%012x",
+		binary.LittleEndian.Uint32(b[0:4]),
+		binary.LittleEndian.Uint16(b[4:6]),
+		binary.LittleEndian.Uint16(b[6:8]),
+		b[8:10],
+		b[10:16],
+	)
+}
+
+// readCompressedName reads a single RFC 1035 §4.1.4-style (DNS message
+// compression) name starting at offset in buf, following at most one
+// backward pointer, and returns the name and the offset immediately after
+// it in the original (uncompressed) stream.
+func readCompressedName(buf []byte, offset int) (name string, next int, err error) {
+	if offset < 0 || offset >= len(buf) {
+		return "", 0, trace.BadParameter("name offset %v out of range", offset)
+	}
+
+	var labels []string
+	pos := offset
+	followedPointer := false
+	for {
+		if pos >= len(buf) {
+			return "", 0, trace.BadParameter("truncated name at offset %v", pos)
+		}
+		length := int(buf[pos])
+		switch {
+		case length == 0:
+			pos++
+			if !followedPointer {
+				next = pos
+			}
+			return strings.Join(labels, "."), next, nil
+		case length&0xC0 == 0xC0:
+			if pos+1 >= len(buf) {
+				return "", 0, trace.BadParameter("truncated name pointer at offset %v", pos)
+			}
+			pointer := (int(length&0x3F) << 8) | int(buf[pos+1])
+			if followedPointer {
+				return "", 0, trace.BadParameter("nested name pointer at offset %v", pos)
+			}
+			next = pos + 2
+			followedPointer = true
+			pos = pointer
+		default:
+			if pos+1+length > len(buf) {
+				return "", 0, trace.BadParameter("truncated name label at offset %v", pos)
+			}
+			labels = append(labels, string(buf[pos+1:pos+1+length]))
+			pos += 1 + length
+		}
+	}
+}
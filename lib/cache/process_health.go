@@ -111,3 +111,88 @@ func (c *Cache) GetProcessHealth(ctx context.Context, name string) (*processheal
 	out, err := getter.get(ctx, name)
 	return out, trace.Wrap(err)
 }
+
+// WatchProcessHealths returns a watcher that streams ProcessHealth resources
+// as they're created, updated, or deleted in the cache.
+func (c *Cache) WatchProcessHealths(ctx context.Context) (services.ProcessHealthWatcher, error) {
+	ctx, span := c.Tracer.Start(ctx, "cache/WatchProcessHealths")
+	defer span.End()
+
+	w, err := c.NewWatcher(ctx, types.Watch{
+		Kinds: []types.WatchKind{{Kind: types.KindProcessHealth}},
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return newProcessHealthWatcher(w), nil
+}
+
+// processHealthWatcher adapts a generic types.Watcher to a
+// services.ProcessHealthWatcher, unwrapping each event's resource into a
+// *processhealthv1.ProcessHealth.
+type processHealthWatcher struct {
+	watcher types.Watcher
+	eventsC chan services.ProcessHealthEvent
+}
+
+func newProcessHealthWatcher(w types.Watcher) *processHealthWatcher {
+	pw := &processHealthWatcher{
+		watcher: w,
+		eventsC: make(chan services.ProcessHealthEvent),
+	}
+	go pw.relay()
+	return pw
+}
+
+func (w *processHealthWatcher) relay() {
+	defer close(w.eventsC)
+	for {
+		select {
+		case event, ok := <-w.watcher.Events():
+			if !ok {
+				return
+			}
+
+			var ph *processhealthv1.ProcessHealth
+			if event.Type == types.OpDelete {
+				// Deletes only carry the resource header, so rebuild a
+				// minimal ProcessHealth carrying just its name.
+				ph = &processhealthv1.ProcessHealth{
+					Kind: types.KindProcessHealth,
+					Metadata: &headerv1.Metadata{
+						Name: event.Resource.GetName(),
+					},
+				}
+			} else {
+				unwrapper, ok := event.Resource.(types.Resource153Unwrapper)
+				if !ok {
+					continue
+				}
+				ph, ok = unwrapper.Unwrap().(*processhealthv1.ProcessHealth)
+				if !ok {
+					continue
+				}
+			}
+
+			select {
+			case w.eventsC <- services.ProcessHealthEvent{Type: event.Type, Resource: ph}:
+			case <-w.watcher.Done():
+				return
+			}
+		case <-w.watcher.Done():
+			return
+		}
+	}
+}
+
+// Events implements services.ProcessHealthWatcher.
+func (w *processHealthWatcher) Events() <-chan services.ProcessHealthEvent { return w.eventsC }
+
+// Done implements services.ProcessHealthWatcher.
+func (w *processHealthWatcher) Done() <-chan struct{} { return w.watcher.Done() }
+
+// Error implements services.ProcessHealthWatcher.
+func (w *processHealthWatcher) Error() error { return w.watcher.Error() }
+
+// Close implements services.ProcessHealthWatcher.
+func (w *processHealthWatcher) Close() error { return w.watcher.Close() }
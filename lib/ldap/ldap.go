@@ -21,14 +21,16 @@ package ldap
 import (
 	"context"
 	"crypto/tls"
-	"log"
+	"log/slog"
 	"net"
 	"os"
 	"time"
 
 	"github.com/go-ldap/ldap/v3"
-	"github.com/gravitational/teleport/lib/auth/windows"
 	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/auth/windows"
 )
 
 const (
@@ -42,16 +44,61 @@ const (
 	ldapRequestTimeout = 45 * time.Second
 )
 
+var log = slog.With(teleport.ComponentKey, "ldap")
+
 // CreateClient creates a new LDAP client by going through addresses in priority
 // order retrieved from the user's domain.
 func CreateClient(ctx context.Context, domain string, site string, ldapTlsConfig *tls.Config) (*ldap.Conn, error) {
-	var resolver *net.Resolver
-	dnsDialer := net.Dialer{
+	resolver, dnsDialer := newResolver(ctx)
+
+	servers, err := windows.LocateLDAPServer(ctx, domain, site, resolver, true)
+	if err != nil {
+		return nil, trace.Wrap(err, "locating LDAP server")
+	}
+
+	if len(servers) == 0 {
+		return nil, trace.NotFound("no LDAP servers found for domain %q", domain)
+	}
+
+	for _, server := range servers {
+		conn, err := dialServer(server, dnsDialer, ldapTlsConfig)
+		if err != nil {
+			// If the connection fails, try the next server
+			log.DebugContext(ctx, "Error connecting to LDAP server", "server", server.Addr(), "error", err)
+			continue
+		}
+
+		return conn, nil
+	}
+
+	return nil, trace.NotFound("no LDAP servers responded successfully for domain %q", domain)
+}
+
+// dialServer dials a single discovered LDAP server and sets the standard
+// request timeout on the resulting connection.
+func dialServer(server windows.DiscoveredDC, dialer *net.Dialer, ldapTlsConfig *tls.Config) (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(
+		"ldaps://"+server.Addr(),
+		ldap.DialWithDialer(dialer),
+		ldap.DialWithTLSConfig(ldapTlsConfig),
+	)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	conn.SetTimeout(ldapRequestTimeout)
+	return conn, nil
+}
+
+// newResolver builds the DNS resolver and dialer used to locate and reach
+// LDAP servers, honoring TELEPORT_DESKTOP_ACCESS_RESOLVER_IP when set.
+func newResolver(ctx context.Context) (*net.Resolver, *net.Dialer) {
+	dnsDialer := &net.Dialer{
 		Timeout: ldapDialTimeout,
 	}
 
+	var resolver *net.Resolver
 	resolverAddr := os.Getenv("TELEPORT_DESKTOP_ACCESS_RESOLVER_IP")
-	log.Printf("DEBUG: TELEPORT_DESKTOP_ACCESS_RESOLVER_IP: %q", resolverAddr)
 	if resolverAddr != "" {
 		// Check if resolver address has a port
 		host, port, err := net.SplitHostPort(resolverAddr)
@@ -60,7 +107,7 @@ func CreateClient(ctx context.Context, domain string, site string, ldapTlsConfig
 			port = "53"
 		}
 		customResolverAddr := net.JoinHostPort(host, port)
-		log.Printf("DEBUG: Using custom resolver address: %s", customResolverAddr)
+		log.DebugContext(ctx, "Using custom DNS resolver", "resolver_addr", customResolverAddr)
 
 		resolver = &net.Resolver{
 			PreferGo: true,
@@ -69,7 +116,7 @@ func CreateClient(ctx context.Context, domain string, site string, ldapTlsConfig
 			},
 		}
 	} else {
-		log.Printf("DEBUG: Using net.DefaultResolver")
+		log.DebugContext(ctx, "Using net.DefaultResolver")
 		resolver = &net.Resolver{
 			PreferGo: true,
 			Dial: func(dialCtx context.Context, network, address string) (net.Conn, error) {
@@ -79,31 +126,5 @@ func CreateClient(ctx context.Context, domain string, site string, ldapTlsConfig
 	}
 	dnsDialer.Resolver = resolver
 
-	servers, err := windows.LocateLDAPServer(ctx, domain, site, resolver)
-	if err != nil {
-		return nil, trace.Wrap(err, "locating LDAP server")
-	}
-
-	if len(servers) == 0 {
-		return nil, trace.NotFound("no LDAP servers found for domain %q", domain)
-	}
-
-	for _, server := range servers {
-		conn, err := ldap.DialURL(
-			"ldaps://"+server,
-			ldap.DialWithDialer(&dnsDialer),
-			ldap.DialWithTLSConfig(ldapTlsConfig),
-		)
-
-		if err != nil {
-			// If the connection fails, try the next server
-			log.Printf("DEBUG: Error connecting to LDAP server %q: %v", server, err)
-			continue
-		}
-
-		conn.SetTimeout(ldapRequestTimeout)
-		return conn, nil
-	}
-
-	return nil, trace.NotFound("no LDAP servers responded successfully for domain %q", domain)
+	return resolver, dnsDialer
 }
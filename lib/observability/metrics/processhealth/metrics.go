@@ -0,0 +1,54 @@
+/*
+ * Teleport
+ * Copyright (C) 2025  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package processhealth exports Prometheus metrics for ProcessHealth status
+// transitions observed by the ProcessHealth gRPC service.
+package processhealth
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/observability/metrics"
+)
+
+var (
+	// StatusGauge reflects whether a tracked process currently holds a given
+	// status: 1 for the status it's currently in, 0 otherwise.
+	StatusGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: teleport.MetricNamespace,
+		Subsystem: "process_health",
+		Name:      "status",
+		Help:      "Current status of each tracked process (1 for its current status, 0 otherwise), labeled by process name and status.",
+	}, []string{"name", "status"})
+
+	// TransitionsTotal counts ProcessHealth status transitions, labeled by
+	// process name and the status transitioned to.
+	TransitionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: teleport.MetricNamespace,
+		Subsystem: "process_health",
+		Name:      "transitions_total",
+		Help:      "Number of ProcessHealth status transitions, labeled by process name and the status transitioned to.",
+	}, []string{"name", "status"})
+)
+
+// Register registers the package's collectors with the default Prometheus
+// registry. Safe to call more than once.
+func Register() error {
+	return metrics.RegisterPrometheusCollectors(StatusGauge, TransitionsTotal)
+}
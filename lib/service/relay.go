@@ -30,6 +30,8 @@ import (
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/gravitational/teleport"
@@ -188,6 +190,10 @@ func (process *TeleportProcess) runRelayService() (retErr error) {
 		return trace.Wrap(err)
 	}
 
+	// The API and tunnel protocols are served on two separate listeners.
+	// Demuxing them onto one port would require picking an ALPN protocol
+	// before the TLS handshake completes, which isn't possible, so there's
+	// no single-port path to build toward here.
 	apiListener, err := process.importOrCreateListener(ListenerRelayAPI, process.Config.Relay.APIListenAddr)
 	if err != nil {
 		return trace.Wrap(err)
@@ -235,6 +241,13 @@ func (process *TeleportProcess) runRelayService() (retErr error) {
 	)
 	defer apiServer.Stop()
 
+	healthServer := health.NewServer()
+	defer healthServer.Shutdown()
+	healthv1.RegisterHealthServer(apiServer, healthServer)
+	// Left NOT_SERVING until startup actually completes below, once the
+	// heartbeat is running and the relay has announced itself as ready.
+	healthServer.SetServingStatus("", healthv1.HealthCheckResponse_NOT_SERVING)
+
 	transportv1pb.RegisterTransportServiceServer(apiServer, transportService)
 	relayv1alpha.RegisterDiscoveryServiceServer(apiServer, &relayapi.StaticDiscoverServiceServer{
 		RelayGroup:            process.Config.Relay.RelayGroup,
@@ -283,6 +296,11 @@ func (process *TeleportProcess) runRelayService() (retErr error) {
 		log.WarnContext(process.ExitContext(), "Failed closing imported file descriptors", "error", err)
 	}
 
+	// The relay is only fit to serve once the heartbeat is running and
+	// descriptor cleanup is done, so gate SERVING on reaching this point
+	// rather than reporting health before startup has actually finished.
+	healthServer.SetServingStatus("", healthv1.HealthCheckResponse_SERVING)
+
 	process.BroadcastEvent(Event{Name: RelayReady})
 	log.InfoContext(process.ExitContext(), "The relay service has successfully started", "nonce", nonce)
 
@@ -306,6 +324,7 @@ func (process *TeleportProcess) runRelayService() (retErr error) {
 	}
 
 	tunnelServer.SetTerminating()
+	healthServer.SetServingStatus("", healthv1.HealthCheckResponse_NOT_SERVING)
 
 	if delay := process.Config.Relay.ShutdownDelay; delay > 0 {
 		log.InfoContext(ctx, "Waiting for the shutdown delay", "delay", delay.String())
@@ -318,8 +337,22 @@ func (process *TeleportProcess) runRelayService() (retErr error) {
 	log.DebugContext(ctx, "Stopping servers")
 	eg, egCtx := errgroup.WithContext(ctx)
 	eg.Go(func() error {
-		// TODO(espadolini): let connections continue (for a time?) before
-		// abruptly terminating them right after the shutdown delay
+		// Give existing tunnel connections a chance to wind down on their
+		// own before forcibly terminating them, the same way the API server
+		// below drains in-flight RPCs with GracefulStop. DrainTimeout bounds
+		// how long we wait, the same way ShutdownDelay above bounds the wait
+		// before we start draining at all; relaytunnel.Server doesn't expose
+		// per-connection counters to poll, so Shutdown's own context deadline
+		// is what bounds the wait rather than an active-count poll loop.
+		drainCtx := egCtx
+		if drain := process.Config.Relay.DrainTimeout; drain > 0 {
+			var cancel context.CancelFunc
+			drainCtx, cancel = context.WithTimeout(egCtx, drain)
+			defer cancel()
+		}
+		if err := tunnelServer.Shutdown(drainCtx); err != nil {
+			log.DebugContext(egCtx, "Tunnel server did not drain in time, closing remaining connections", "error", err)
+		}
 		tunnelServer.Close()
 		return nil
 	})
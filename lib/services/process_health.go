@@ -22,6 +22,7 @@ import (
 	"context"
 
 	processhealthv1 "github.com/gravitational/teleport/api/gen/proto/go/teleport/processhealth/v1"
+	"github.com/gravitational/teleport/api/types"
 )
 
 // ProcessHealth is the interface for managing user tasks resources.
@@ -32,6 +33,35 @@ type ProcessHealth interface {
 	GetProcessHealth(ctx context.Context, name string) (*processhealthv1.ProcessHealth, error)
 	// ListProcessHealth returns the user tasks resources.
 	ListProcessHealths(ctx context.Context, pageSize int64, nextToken string) ([]*processhealthv1.ProcessHealth, string, error)
+	// WatchProcessHealths streams ProcessHealth create/update/delete events,
+	// so callers don't have to poll ListProcessHealths to notice changes.
+	WatchProcessHealths(ctx context.Context) (ProcessHealthWatcher, error)
+}
+
+// ProcessHealthEvent is a single create/update/delete notification yielded
+// by a ProcessHealthWatcher.
+type ProcessHealthEvent struct {
+	// Type is types.OpPut for a created or updated ProcessHealth, or
+	// types.OpDelete when one is removed.
+	Type types.OpType
+	// Resource is the ProcessHealth the event applies to. For OpDelete
+	// events only Resource.Metadata.Name is populated.
+	Resource *processhealthv1.ProcessHealth
+}
+
+// ProcessHealthWatcher streams ProcessHealth resources as they're created,
+// updated, or deleted, so callers don't have to poll ListProcessHealths to
+// notice changes.
+type ProcessHealthWatcher interface {
+	// Events yields a ProcessHealthEvent each time one changes.
+	Events() <-chan ProcessHealthEvent
+	// Done is closed once the watcher has stopped, whether because Close
+	// was called or because the underlying stream ended.
+	Done() <-chan struct{}
+	// Error returns the error, if any, that stopped the watcher.
+	Error() error
+	// Close stops the watcher and releases its resources.
+	Close() error
 }
 
 // MarshalProcessHealth marshals the ProcessHealth object into a JSON byte array.
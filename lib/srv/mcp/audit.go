@@ -20,6 +20,8 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
+	"slices"
 
 	"github.com/mark3labs/mcp-go/mcp"
 
@@ -30,24 +32,39 @@ import (
 	"github.com/gravitational/teleport/lib/utils/mcputils"
 )
 
-func shouldEmitEvent(method mcp.MCPMethod) bool {
-	switch method {
-	case mcp.MethodPing,
-		mcp.MethodResourcesList,
-		mcp.MethodResourcesTemplatesList,
-		mcp.MethodPromptsList,
-		mcp.MethodToolsList:
-		return false
-	default:
-		return true
-	}
+// noisyMethods are JSON-RPC methods that are filtered out of the audit log
+// by default because they're high-volume and low-value (simple liveness
+// checks and capability listings). An app can opt back into auditing any of
+// these through ServerConfig.EventAllowlist, e.g. while investigating an
+// incident.
+var noisyMethods = map[mcp.MCPMethod]struct{}{
+	mcp.MethodPing:                   {},
+	mcp.MethodResourcesList:          {},
+	mcp.MethodResourcesTemplatesList: {},
+	mcp.MethodPromptsList:            {},
+	mcp.MethodToolsList:              {},
 }
 
+// maxAuditResultSize caps the size, in bytes, of a JSON-RPC params or result
+// body recorded in an audit event. Larger bodies are truncated; only a
+// truncated result body is flagged, via ResultTruncated.
+const maxAuditResultSize = 8 * 1024
+
 type auditor struct {
 	// cfg reuses a valid ServerConfig (because i'm lazy).
 	cfg ServerConfig
 }
 
+// shouldEmitEvent reports whether a notification or request for method
+// should be audited for the given app, taking into account the app's entry
+// (if any) in cfg.EventAllowlist.
+func (a *auditor) shouldEmitEvent(appName string, method mcp.MCPMethod) bool {
+	if _, noisy := noisyMethods[method]; !noisy {
+		return true
+	}
+	return slices.Contains(a.cfg.EventAllowlist[appName], method)
+}
+
 func (a *auditor) emitStartEvent(ctx context.Context, session *SessionCtx) {
 	a.emitEvent(ctx, &apievents.MCPSessionStart{
 		Metadata: apievents.Metadata{
@@ -79,7 +96,7 @@ func (a *auditor) emitEndEvent(ctx context.Context, session *SessionCtx) {
 }
 
 func (a *auditor) emitNotificationEvent(ctx context.Context, session *SessionCtx, msg *mcputils.JSONRPCNotification) {
-	if !shouldEmitEvent(msg.Method) {
+	if !a.shouldEmitEvent(session.App.GetName(), msg.Method) {
 		return
 	}
 	a.emitEvent(ctx, &apievents.MCPSessionNotification{
@@ -94,13 +111,13 @@ func (a *auditor) emitNotificationEvent(ctx context.Context, session *SessionCtx
 		Message: apievents.MCPJSONRPCMessage{
 			JSONRPC: msg.JSONRPC,
 			Method:  string(msg.Method),
-			Params:  msg.Params.GetEventParams(),
+			Params:  a.redactParams(msg.Method, msg.Params.GetEventParams()),
 		},
 	})
 }
 
 func (a *auditor) emitRequestEvent(ctx context.Context, session *SessionCtx, msg *mcputils.JSONRPCRequest, err error) {
-	if !shouldEmitEvent(msg.Method) && err == nil {
+	if !a.shouldEmitEvent(session.App.GetName(), msg.Method) && err == nil {
 		return
 	}
 	event := &apievents.MCPSessionRequest{
@@ -119,7 +136,7 @@ func (a *auditor) emitRequestEvent(ctx context.Context, session *SessionCtx, msg
 			JSONRPC: msg.JSONRPC,
 			Method:  string(msg.Method),
 			ID:      msg.ID.String(),
-			Params:  msg.Params.GetEventParams(),
+			Params:  a.redactParams(msg.Method, msg.Params.GetEventParams()),
 		},
 	}
 
@@ -131,6 +148,89 @@ func (a *auditor) emitRequestEvent(ctx context.Context, session *SessionCtx, msg
 	a.emitEvent(ctx, event)
 }
 
+// emitResponseEvent records the result of a tool call (or any other request)
+// so operators can tell what data flowed back out of an MCP server, not just
+// what was asked of it. Responses are correlated to their request by
+// JSON-RPC ID.
+func (a *auditor) emitResponseEvent(ctx context.Context, session *SessionCtx, req *mcputils.JSONRPCRequest, resp *mcputils.JSONRPCResponse, err error) {
+	if !a.shouldEmitEvent(session.App.GetName(), req.Method) && err == nil {
+		return
+	}
+
+	result, truncated := a.redactAndCapResult(req.Method, resp)
+	event := &apievents.MCPSessionResponse{
+		Metadata: apievents.Metadata{
+			Type:        events.MCPSessionResponseEvent,
+			Code:        events.MCPSessionResponseCode,
+			ClusterName: session.Identity.RouteToApp.ClusterName,
+		},
+		SessionMetadata: a.makeSessionMetadata(session),
+		UserMetadata:    session.Identity.GetUserMetadata(),
+		AppMetadata:     a.makeSessionAppMetadata(session),
+		Status: apievents.Status{
+			Success: true,
+		},
+		Message: apievents.MCPJSONRPCMessage{
+			JSONRPC: req.JSONRPC,
+			Method:  string(req.Method),
+			ID:      req.ID.String(),
+			Result:  result,
+		},
+		ResultTruncated: truncated,
+	}
+
+	if err != nil {
+		event.Metadata.Code = events.MCPSessionResponseFailureCode
+		event.Status.Success = false
+		event.Status.Error = err.Error()
+	}
+	a.emitEvent(ctx, event)
+}
+
+// redactor returns the configured Redactor, falling back to the default
+// implementation when ServerConfig doesn't supply one.
+func (a *auditor) redactor() Redactor {
+	if a.cfg.Redactor != nil {
+		return a.cfg.Redactor
+	}
+	return defaultRedactor{}
+}
+
+func (a *auditor) redactParams(method mcp.MCPMethod, params any) string {
+	return toCappedJSON(a.redactor().RedactParams(method, params))
+}
+
+// redactAndCapResult redacts and size-caps a JSON-RPC response body,
+// reporting whether the body was truncated to fit maxAuditResultSize.
+func (a *auditor) redactAndCapResult(method mcp.MCPMethod, resp *mcputils.JSONRPCResponse) (string, bool) {
+	if resp == nil {
+		return "", false
+	}
+	redacted := a.redactor().RedactResult(method, resp.Result)
+	data, err := json.Marshal(redacted)
+	if err != nil {
+		return "", false
+	}
+	if len(data) <= maxAuditResultSize {
+		return string(data), false
+	}
+	return string(data[:maxAuditResultSize]), true
+}
+
+// toCappedJSON marshals v to a JSON string for inclusion in an audit event,
+// capping its length so a single oversized tool result can't blow up the
+// audit log.
+func toCappedJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	if len(data) > maxAuditResultSize {
+		data = data[:maxAuditResultSize]
+	}
+	return string(data)
+}
+
 func (a *auditor) emitEvent(ctx context.Context, event apievents.AuditEvent) {
 	if err := a.cfg.Emitter.EmitAuditEvent(ctx, event); err != nil {
 		a.cfg.Log.DebugContext(ctx, "Failed to emit audit event", "error", err)
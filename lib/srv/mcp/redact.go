@@ -0,0 +1,99 @@
+/*
+ * Teleport
+ * Copyright (C) 2025  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mcp
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Redactor sanitizes MCP request parameters and response results before
+// they are written to the audit log.
+type Redactor interface {
+	// RedactParams returns a version of params, as decoded from the JSON-RPC
+	// message for method, with sensitive fields removed or masked.
+	RedactParams(method mcp.MCPMethod, params any) any
+	// RedactResult returns a version of result, as decoded from the tool call
+	// response for method, with sensitive fields removed or masked.
+	RedactResult(method mcp.MCPMethod, result any) any
+}
+
+// redactedValue replaces a sensitive field or value in the audit log.
+const redactedValue = "[REDACTED]"
+
+// defaultRedactor is the Redactor used when ServerConfig does not supply one.
+// It walks decoded JSON values recursively, masking well-known secret-bearing
+// field names and values that look like bearer tokens or JWTs regardless of
+// the field they're stored under.
+type defaultRedactor struct{}
+
+// sensitiveFieldNames are object keys, compared case-insensitively, whose
+// values are always redacted.
+var sensitiveFieldNames = map[string]struct{}{
+	"password":      {},
+	"token":         {},
+	"authorization": {},
+	"api_key":       {},
+	"apikey":        {},
+	"secret":        {},
+}
+
+// bearerLikeValue matches strings that look like a bearer token or a JWT, so
+// they get redacted even when stored under an innocuous-looking field name.
+var bearerLikeValue = regexp.MustCompile(`(?i)^(bearer\s+\S+|[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+)$`)
+
+// RedactParams implements Redactor.
+func (defaultRedactor) RedactParams(_ mcp.MCPMethod, params any) any {
+	return redactValue(params)
+}
+
+// RedactResult implements Redactor.
+func (defaultRedactor) RedactResult(_ mcp.MCPMethod, result any) any {
+	return redactValue(result)
+}
+
+func redactValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, fieldVal := range val {
+			if _, sensitive := sensitiveFieldNames[strings.ToLower(k)]; sensitive {
+				out[k] = redactedValue
+				continue
+			}
+			out[k] = redactValue(fieldVal)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = redactValue(item)
+		}
+		return out
+	case string:
+		if bearerLikeValue.MatchString(val) {
+			return redactedValue
+		}
+		return val
+	default:
+		return val
+	}
+}
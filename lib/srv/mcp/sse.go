@@ -34,7 +34,7 @@ func (s *Server) handleStdioToSSE(ctx context.Context, sessionCtx SessionCtx) er
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	baseURL, err := makeSSEBaseURI(sessionCtx.App)
+	baseURL, err := makeMCPBaseURI(sessionCtx.App)
 	if err != nil {
 		return trace.Wrap(err, "parsing SSE URI")
 	}
@@ -46,7 +46,7 @@ func (s *Server) handleStdioToSSE(ctx context.Context, sessionCtx SessionCtx) er
 	session.logger.DebugContext(s.cfg.ParentContext, "Started handling stdio to SSE session", "base_uri", logutils.StringerAttr(baseURL))
 	defer session.logger.DebugContext(s.cfg.ParentContext, "Completed handling stdio to SSE session")
 
-	serverTransportReader, serverRequestWriter, err := mcputils.ConnectSSEServer(ctx, baseURL)
+	serverTransportReader, serverRequestWriter, err := mcputils.ConnectSSEServer(ctx, baseURL, nil)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -83,16 +83,22 @@ func (s *Server) handleStdioToSSE(ctx context.Context, sessionCtx SessionCtx) er
 	return nil
 }
 
-func makeSSEBaseURI(app types.Application) (*url.URL, error) {
+// makeMCPBaseURI parses the app's declared URI and rewrites its scheme to
+// the plain "http"/"https" scheme the underlying transport actually dials,
+// stripping the "mcp+..." prefix used to tell Teleport which transport and
+// network scheme to use. It's shared by both the SSE and Streamable HTTP
+// handlers since the two only differ in how they speak to baseURL once
+// resolved.
+func makeMCPBaseURI(app types.Application) (*url.URL, error) {
 	baseURL, err := url.Parse(app.GetURI())
 	if err != nil {
-		return nil, trace.Wrap(err, "parsing SSE URI")
+		return nil, trace.Wrap(err, "parsing MCP server URI")
 	}
 	transportType := types.GetMCPServerTransportType(app.GetURI())
 	switch transportType {
-	case types.MCPTransportSSEHTTP:
+	case types.MCPTransportSSEHTTP, types.MCPTransportStreamableHTTP:
 		baseURL.Scheme = "http"
-	case types.MCPTransportSSEHTTPS:
+	case types.MCPTransportSSEHTTPS, types.MCPTransportStreamableHTTPS:
 		baseURL.Scheme = "https"
 	default:
 		return nil, trace.BadParameter("unknown transport type: %v", transportType)
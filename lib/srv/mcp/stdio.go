@@ -21,20 +21,13 @@ package mcp
 import (
 	"context"
 	"io"
-	"log/slog"
 	"net"
-	"os"
 	"os/exec"
-	"os/user"
-	"sync"
-	"syscall"
-	"time"
 
 	"github.com/gravitational/trace"
 	"github.com/mark3labs/mcp-go/mcp"
 
 	"github.com/gravitational/teleport/lib/utils"
-	hostutils "github.com/gravitational/teleport/lib/utils/host"
 	"github.com/gravitational/teleport/lib/utils/mcputils"
 )
 
@@ -161,57 +154,5 @@ func (s *Server) handleStdio(ctx context.Context, sessionCtx SessionCtx) error {
 	return nil
 }
 
-func setGracefulStop(parentContext context.Context, cmd *exec.Cmd, logger *slog.Logger) chan struct{} {
-	if cmd.SysProcAttr == nil {
-		cmd.SysProcAttr = &syscall.SysProcAttr{}
-	}
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
-	}
-	processDone := make(chan struct{}, 1)
-	cmd.Cancel = sync.OnceValue(func() error {
-		pgid, err := syscall.Getpgid(cmd.Process.Pid)
-		if err != nil {
-			return trace.Wrap(err, "getting pgid for process")
-		}
-
-		// Use syscall.SIGINT first as it's the common way to gracefully stop
-		// MCP servers listening on stdin.
-		if err := syscall.Kill(-pgid, syscall.SIGINT); err != nil {
-			return trace.Wrap(syscall.Kill(-pgid, syscall.SIGKILL))
-		}
-
-		select {
-		case <-processDone:
-			logger.DebugContext(parentContext, "Process exited gracefully")
-			return nil
-		case <-time.After(10 * time.Second):
-			logger.DebugContext(parentContext, "Process did not exit gracefully, killing with SIGKILL")
-			return trace.Wrap(syscall.Kill(-pgid, syscall.SIGKILL))
-		}
-	})
-	return processDone
-}
-
-func setRunAsHostUser(ctx context.Context, cmd *exec.Cmd, localUserName string, logger *slog.Logger) error {
-	localUser, err := user.Lookup(localUserName)
-	if err != nil {
-		return trace.Wrap(err, "finding local user")
-	}
-	cred, err := hostutils.GetHostUserCredential(localUser)
-	if err != nil {
-		return trace.Wrap(err, "getting local user credential")
-	}
-
-	if os.Getuid() == int(cred.Uid) || os.Getgid() == int(cred.Gid) {
-		logger.DebugContext(ctx, "Launching process with ambient credentials")
-		return nil
-	}
-
-	logger.DebugContext(ctx, "Launching process as local user", "user", localUserName, "uid", cred.Uid, "gid", cred.Gid)
-	if cmd.SysProcAttr == nil {
-		cmd.SysProcAttr = &syscall.SysProcAttr{}
-	}
-	cmd.SysProcAttr.Credential = cred
-	return nil
-}
+// setGracefulStop and setRunAsHostUser are platform-specific; see
+// stdio_unix.go and stdio_windows.go.
@@ -0,0 +1,91 @@
+//go:build unix
+
+/*
+ * Teleport
+ * Copyright (C) 2025  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mcp
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/user"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	hostutils "github.com/gravitational/teleport/lib/utils/host"
+)
+
+func setGracefulStop(parentContext context.Context, cmd *exec.Cmd, logger *slog.Logger) chan struct{} {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+	processDone := make(chan struct{}, 1)
+	cmd.Cancel = sync.OnceValue(func() error {
+		pgid, err := syscall.Getpgid(cmd.Process.Pid)
+		if err != nil {
+			return trace.Wrap(err, "getting pgid for process")
+		}
+
+		// Use syscall.SIGINT first as it's the common way to gracefully stop
+		// MCP servers listening on stdin.
+		if err := syscall.Kill(-pgid, syscall.SIGINT); err != nil {
+			return trace.Wrap(syscall.Kill(-pgid, syscall.SIGKILL))
+		}
+
+		select {
+		case <-processDone:
+			logger.DebugContext(parentContext, "Process exited gracefully")
+			return nil
+		case <-time.After(10 * time.Second):
+			logger.DebugContext(parentContext, "Process did not exit gracefully, killing with SIGKILL")
+			return trace.Wrap(syscall.Kill(-pgid, syscall.SIGKILL))
+		}
+	})
+	return processDone
+}
+
+func setRunAsHostUser(ctx context.Context, cmd *exec.Cmd, localUserName string, logger *slog.Logger) error {
+	localUser, err := user.Lookup(localUserName)
+	if err != nil {
+		return trace.Wrap(err, "finding local user")
+	}
+	cred, err := hostutils.GetHostUserCredential(localUser)
+	if err != nil {
+		return trace.Wrap(err, "getting local user credential")
+	}
+
+	if os.Getuid() == int(cred.Uid) || os.Getgid() == int(cred.Gid) {
+		logger.DebugContext(ctx, "Launching process with ambient credentials")
+		return nil
+	}
+
+	logger.DebugContext(ctx, "Launching process as local user", "user", localUserName, "uid", cred.Uid, "gid", cred.Gid)
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = cred
+	return nil
+}
@@ -0,0 +1,103 @@
+//go:build windows
+
+/*
+ * Teleport
+ * Copyright (C) 2025  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mcp
+
+import (
+	"context"
+	"log/slog"
+	"os/exec"
+	"os/user"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gravitational/trace"
+	"golang.org/x/sys/windows"
+)
+
+// setGracefulStop puts cmd in its own console process group, so that it can
+// be sent a CTRL_BREAK_EVENT independent of this process's own console, and
+// arranges for cmd.Cancel to request that graceful stop before falling back
+// to TerminateProcess after the same grace period the unix implementation
+// allows.
+func setGracefulStop(parentContext context.Context, cmd *exec.Cmd, logger *slog.Logger) chan struct{} {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= windows.CREATE_NEW_PROCESS_GROUP
+
+	processDone := make(chan struct{}, 1)
+	cmd.Cancel = sync.OnceValue(func() error {
+		// CREATE_NEW_PROCESS_GROUP made cmd's PID double as its own console
+		// process group ID, so this only reaches cmd (and any children it
+		// spawned), not this process's console.
+		if err := windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(cmd.Process.Pid)); err != nil {
+			return trace.Wrap(cmd.Process.Kill())
+		}
+
+		select {
+		case <-processDone:
+			logger.DebugContext(parentContext, "Process exited gracefully")
+			return nil
+		case <-time.After(10 * time.Second):
+			logger.DebugContext(parentContext, "Process did not exit gracefully, killing")
+			return trace.Wrap(cmd.Process.Kill())
+		}
+	})
+	return processDone
+}
+
+// setRunAsHostUser runs cmd as the ambient user when localUserName matches
+// it. Impersonating a different local user would require a logon token for
+// that user (LogonUser/CreateProcessAsUser), which needs either the user's
+// password or an S4U logon that only domain controllers or services running
+// as LocalSystem can perform; until that path is implemented, requests to
+// run as any other user are refused with a clear error rather than silently
+// falling back to the ambient identity.
+func setRunAsHostUser(ctx context.Context, cmd *exec.Cmd, localUserName string, logger *slog.Logger) error {
+	current, err := user.Current()
+	if err != nil {
+		return trace.Wrap(err, "getting current user")
+	}
+	if !sameWindowsUser(current.Username, localUserName) {
+		return trace.NotImplemented("running MCP servers as a local user other than %q is not yet supported on Windows", current.Username)
+	}
+
+	logger.DebugContext(ctx, "Launching process with ambient credentials")
+	return nil
+}
+
+// sameWindowsUser reports whether a and b name the same local account,
+// tolerating the presence or absence of a "DOMAIN\" or ".\" prefix on either
+// side, since cmd.SysProcAttr.Credential is unix-specific and Windows
+// accounts are usually compared by their unqualified name.
+func sameWindowsUser(a, b string) bool {
+	return unqualifiedWindowsUser(a) == unqualifiedWindowsUser(b)
+}
+
+func unqualifiedWindowsUser(name string) string {
+	for i := len(name) - 1; i >= 0; i-- {
+		if name[i] == '\\' {
+			return name[i+1:]
+		}
+	}
+	return name
+}
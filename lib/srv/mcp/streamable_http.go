@@ -0,0 +1,87 @@
+/*
+ * Teleport
+ * Copyright (C) 2025  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mcp
+
+import (
+	"context"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/lib/utils"
+	logutils "github.com/gravitational/teleport/lib/utils/log"
+	"github.com/gravitational/teleport/lib/utils/mcputils"
+)
+
+// handleStdioToStreamableHTTP bridges a local stdio-speaking client to an
+// upstream MCP server that speaks the Streamable HTTP transport. It mirrors
+// handleStdioToSSE: the same mcputils message reader/writer plumbing, the
+// same session handler, and the same audit behavior, just connected to a
+// different upstream transport.
+func (s *Server) handleStdioToStreamableHTTP(ctx context.Context, sessionCtx SessionCtx) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	baseURL, err := makeMCPBaseURI(sessionCtx.App)
+	if err != nil {
+		return trace.Wrap(err, "parsing Streamable HTTP URI")
+	}
+	session, err := s.makeSessionHandler(ctx, &sessionCtx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	session.logger.DebugContext(s.cfg.ParentContext, "Started handling stdio to Streamable HTTP session", "base_uri", logutils.StringerAttr(baseURL))
+	defer session.logger.DebugContext(s.cfg.ParentContext, "Completed handling stdio to Streamable HTTP session")
+
+	serverTransportReader, serverRequestWriter, err := mcputils.ConnectStreamableHTTPServer(ctx, baseURL, nil)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	if externalSessionID := serverRequestWriter.GetSessionID(); externalSessionID != "" {
+		session.externalSessionID = externalSessionID
+		session.logger.DebugContext(s.cfg.ParentContext, "Found external session ID", "session_id", externalSessionID)
+	}
+
+	clientResponseWriter := mcputils.NewStdioMessageWriter(utils.NewSyncWriter(sessionCtx.ClientConn))
+	stdoutLogger := session.logger.With("streamable_http", "stdout")
+	serverResponseReader, err := mcputils.NewMessageReader(mcputils.MessageReaderConfig{
+		Transport:      serverTransportReader,
+		Logger:         stdoutLogger,
+		ParentContext:  s.cfg.ParentContext,
+		OnClose:        cancel,
+		OnParseError:   mcputils.LogAndIgnoreParseError(stdoutLogger),
+		OnNotification: session.onServerNotification(clientResponseWriter),
+		OnResponse:     session.onServerResponse(clientResponseWriter),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	go serverResponseReader.Run(ctx)
+
+	clientRequestReader, err := makeStdioClientRequestReader(session, clientResponseWriter, serverRequestWriter, cancel)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	session.emitStartEvent(session.parentCtx)
+	defer session.emitEndEvent(session.parentCtx)
+	clientRequestReader.Run(ctx)
+	return nil
+}
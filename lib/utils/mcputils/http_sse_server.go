@@ -0,0 +1,167 @@
+/*
+ * Teleport
+ * Copyright (C) 2025  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mcputils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/gravitational/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// HTTPSSEServerTransport implements TransportReader and MessageWriter for
+// the legacy HTTP+SSE transport, with Teleport playing the server role:
+// incoming client messages are POSTed to the endpoint HandlePOST serves,
+// and outgoing messages are streamed back over the SSE response HandleSSE
+// serves, exactly mirroring what ConnectSSEServer expects from the other
+// side of the connection.
+type HTTPSSEServerTransport struct {
+	// postEndpoint is sent to the client in the initial "endpoint" event as
+	// the URI it should POST messages to.
+	postEndpoint string
+
+	messages chan string
+	done     chan struct{}
+	closeOne sync.Once
+
+	mu      sync.Mutex
+	sseConn http.ResponseWriter
+	flush   func()
+	ready   chan struct{}
+}
+
+// NewHTTPSSEServerTransport creates an HTTPSSEServerTransport. postEndpoint
+// is advertised to the client as the URI to POST subsequent messages to.
+func NewHTTPSSEServerTransport(postEndpoint string) *HTTPSSEServerTransport {
+	return &HTTPSSEServerTransport{
+		postEndpoint: postEndpoint,
+		messages:     make(chan string),
+		done:         make(chan struct{}),
+		ready:        make(chan struct{}),
+	}
+}
+
+// Type returns "sse".
+func (t *HTTPSSEServerTransport) Type() string {
+	return "sse"
+}
+
+// Close stops the transport. Any handler blocked in HandleSSE or HandlePOST
+// returns shortly after.
+func (t *HTTPSSEServerTransport) Close() error {
+	t.closeOne.Do(func() { close(t.done) })
+	return nil
+}
+
+// ReadMessage returns the next message delivered to HandlePOST.
+func (t *HTTPSSEServerTransport) ReadMessage(ctx context.Context) (string, error) {
+	select {
+	case msg, ok := <-t.messages:
+		if !ok {
+			return "", io.EOF
+		}
+		return msg, nil
+	case <-ctx.Done():
+		return "", trace.Wrap(ctx.Err())
+	case <-t.done:
+		return "", io.EOF
+	}
+}
+
+// WriteMessage streams msg to the connected SSE client as a "message"
+// event. It blocks until a client has connected via HandleSSE.
+func (t *HTTPSSEServerTransport) WriteMessage(ctx context.Context, msg mcp.JSONRPCMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return trace.Wrap(err, "marshalling message")
+	}
+
+	select {
+	case <-t.ready:
+	case <-ctx.Done():
+		return trace.Wrap(ctx.Err())
+	case <-t.done:
+		return trace.Errorf("transport closed")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := fmt.Fprintf(t.sseConn, "event: %s\ndata: %s\n\n", SSEEventMessage, data); err != nil {
+		return trace.Wrap(err, "writing SSE message")
+	}
+	t.flush()
+	return nil
+}
+
+// HandleSSE serves the long-lived GET endpoint clients connect to first.
+// Per the legacy SSE transport, the first event sent is an "endpoint" event
+// telling the client where to POST its messages; every subsequent
+// WriteMessage call is streamed to this same connection as a "message"
+// event. HandleSSE blocks until the request is canceled or the transport is
+// closed, so it should be called from its own goroutine per incoming
+// connection, as net/http already does for each handler.
+func (t *HTTPSSEServerTransport) HandleSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	t.mu.Lock()
+	t.sseConn = w
+	t.flush = flusher.Flush
+	t.mu.Unlock()
+	close(t.ready)
+
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", SSEEventEndpoint, t.postEndpoint)
+	flusher.Flush()
+
+	select {
+	case <-r.Context().Done():
+	case <-t.done:
+	}
+}
+
+// HandlePOST accepts a single JSON-RPC message posted by the client and
+// delivers it to ReadMessage. The legacy SSE transport sends responses over
+// the SSE stream, not in the POST response, so HandlePOST only ever
+// acknowledges receipt.
+func (t *HTTPSSEServerTransport) HandlePOST(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case t.messages <- string(data):
+		w.WriteHeader(http.StatusAccepted)
+	case <-r.Context().Done():
+	case <-t.done:
+		http.Error(w, "transport closed", http.StatusGone)
+	}
+}
@@ -0,0 +1,637 @@
+/*
+ * Teleport
+ * Copyright (C) 2025  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mcputils
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport"
+)
+
+const (
+	// protectedResourceMetadataPath is the well-known path an MCP server's
+	// WWW-Authenticate challenge points a client at to discover which
+	// authorization server(s) protect it, per RFC 9728.
+	protectedResourceMetadataPath = "/.well-known/oauth-protected-resource"
+	// authServerMetadataPath is the well-known path used to discover an
+	// authorization server's endpoints, per RFC 8414.
+	authServerMetadataPath = "/.well-known/oauth-authorization-server"
+
+	// defaultOAuthCallbackTimeout bounds how long ConnectSSEServer and
+	// ConnectStreamableHTTPServer wait for the user to finish the browser
+	// consent step before giving up.
+	defaultOAuthCallbackTimeout = 2 * time.Minute
+)
+
+// OAuthToken is an access/refresh token pair obtained from an MCP server's
+// authorization server.
+type OAuthToken struct {
+	// AccessToken is injected as an "Authorization" header.
+	AccessToken string
+	// RefreshToken, if any, is used to obtain a new AccessToken once it
+	// expires without repeating the interactive consent flow.
+	RefreshToken string
+	// TokenType is the token_type returned alongside AccessToken, usually
+	// "Bearer".
+	TokenType string
+	// ExpiresAt is when AccessToken stops being valid. Zero means unknown,
+	// and the token is used until the server rejects it.
+	ExpiresAt time.Time
+}
+
+// Expired reports whether t should be refreshed before use.
+func (t *OAuthToken) Expired() bool {
+	return t == nil || (!t.ExpiresAt.IsZero() && time.Now().After(t.ExpiresAt))
+}
+
+// AuthorizationHeader returns the value to send as the "Authorization"
+// request header.
+func (t *OAuthToken) AuthorizationHeader() string {
+	return t.TokenType + " " + t.AccessToken
+}
+
+// TokenStore persists OAuth tokens across reconnects, keyed by the resource
+// URL of the MCP server they authorize access to.
+type TokenStore interface {
+	// GetToken returns the cached token for resource, or nil if there isn't
+	// one.
+	GetToken(resource string) (*OAuthToken, error)
+	// PutToken caches token for resource.
+	PutToken(resource string, token *OAuthToken) error
+}
+
+// MemoryTokenStore is a TokenStore that keeps tokens in process memory. It's
+// the default used when OAuthConfig.TokenStore is unset; callers that want
+// tokens to survive process restarts should supply their own TokenStore
+// (e.g. a keyring-backed implementation).
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]*OAuthToken
+}
+
+// NewMemoryTokenStore creates an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: make(map[string]*OAuthToken)}
+}
+
+// GetToken implements TokenStore.
+func (s *MemoryTokenStore) GetToken(resource string) (*OAuthToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[resource], nil
+}
+
+// PutToken implements TokenStore.
+func (s *MemoryTokenStore) PutToken(resource string, token *OAuthToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[resource] = token
+	return nil
+}
+
+// BrowserOpener opens a URL in the user's browser so they can complete an
+// interactive authorization consent step.
+type BrowserOpener interface {
+	OpenURL(url string) error
+}
+
+// BrowserOpenerFunc adapts a function to a BrowserOpener.
+type BrowserOpenerFunc func(url string) error
+
+// OpenURL implements BrowserOpener.
+func (f BrowserOpenerFunc) OpenURL(url string) error { return f(url) }
+
+// defaultBrowserOpener shells out to the OS's "open a URL" command.
+var defaultBrowserOpener = BrowserOpenerFunc(func(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return trace.Wrap(cmd.Start())
+})
+
+// OAuthConfig configures the MCP OAuth 2.1 authorization flow that
+// ConnectSSEServer and ConnectStreamableHTTPServer run when the upstream
+// server challenges a request with a "WWW-Authenticate: Bearer" header.
+type OAuthConfig struct {
+	// ClientName is the client_name sent during dynamic client registration.
+	ClientName string
+	// ClientID, if set, skips dynamic client registration (RFC 7591) and
+	// uses this statically-configured client_id instead, for authorization
+	// servers that don't support it.
+	ClientID string
+	// Scopes is the list of scopes requested during authorization.
+	Scopes []string
+	// TokenStore persists tokens across reconnects. Defaults to a
+	// MemoryTokenStore.
+	TokenStore TokenStore
+	// BrowserOpener opens the authorization URL for the user to approve.
+	// Defaults to the OS's "open URL" command.
+	BrowserOpener BrowserOpener
+	// CallbackTimeout bounds how long to wait for the loopback redirect
+	// after opening the browser. Defaults to defaultOAuthCallbackTimeout.
+	CallbackTimeout time.Duration
+	// Logger is used to report non-fatal issues, such as a failed token
+	// refresh that falls back to the full interactive flow.
+	Logger *slog.Logger
+}
+
+// CheckAndSetDefaults checks values and sets defaults.
+func (c *OAuthConfig) CheckAndSetDefaults() error {
+	if c.ClientName == "" {
+		c.ClientName = "teleport-mcp"
+	}
+	if c.TokenStore == nil {
+		c.TokenStore = NewMemoryTokenStore()
+	}
+	if c.BrowserOpener == nil {
+		c.BrowserOpener = defaultBrowserOpener
+	}
+	if c.CallbackTimeout <= 0 {
+		c.CallbackTimeout = defaultOAuthCallbackTimeout
+	}
+	if c.Logger == nil {
+		c.Logger = slog.With(teleport.ComponentKey, "mcp")
+	}
+	return nil
+}
+
+// protectedResourceMetadata is the document served at
+// protectedResourceMetadataPath, per RFC 9728.
+type protectedResourceMetadata struct {
+	AuthorizationServers []string `json:"authorization_servers"`
+}
+
+// authServerMetadata is the document served at authServerMetadataPath, per
+// RFC 8414.
+type authServerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	RegistrationEndpoint  string `json:"registration_endpoint"`
+}
+
+// clientRegistration is the subset of an RFC 7591 dynamic client
+// registration response this package needs.
+type clientRegistration struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// tokenResponse is the subset of an RFC 6749 token endpoint response this
+// package needs.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// oauthAuthorizer drives the OAuth 2.1 discovery, dynamic client
+// registration, and authorization-code + PKCE flow for a single MCP server,
+// caching the resulting token in cfg.TokenStore keyed by resource.
+type oauthAuthorizer struct {
+	cfg      OAuthConfig
+	client   *http.Client
+	resource string
+
+	mu           sync.Mutex
+	authMeta     *authServerMetadata
+	registration *clientRegistration
+}
+
+func newOAuthAuthorizer(client *http.Client, resource *url.URL, cfg OAuthConfig) *oauthAuthorizer {
+	return &oauthAuthorizer{
+		cfg:      cfg,
+		client:   client,
+		resource: resource.String(),
+	}
+}
+
+// authorizationHeader returns the "Authorization" header value to attach to
+// a request, using a cached (and refreshed, if necessary) token. It returns
+// an empty string if there's no cached token yet, in which case the caller
+// is expected to send the request unauthenticated and call authorize once
+// challenged.
+func (a *oauthAuthorizer) authorizationHeader(ctx context.Context) (string, error) {
+	tok, err := a.cfg.TokenStore.GetToken(a.resource)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	if tok == nil {
+		return "", nil
+	}
+	if tok.Expired() && tok.RefreshToken != "" {
+		if refreshed, err := a.refresh(ctx, tok.RefreshToken); err != nil {
+			a.cfg.Logger.DebugContext(ctx, "Failed to refresh OAuth token, will re-authorize interactively if challenged", "error", err)
+		} else {
+			tok = refreshed
+		}
+	}
+	return tok.AuthorizationHeader(), nil
+}
+
+// authorize runs the full discovery and authorization-code + PKCE flow
+// triggered by a WWW-Authenticate challenge, caches the resulting token, and
+// returns the "Authorization" header value to retry the challenged request
+// with.
+func (a *oauthAuthorizer) authorize(ctx context.Context, wwwAuthenticate string) (string, error) {
+	resourceMetadataURL, err := parseBearerChallenge(wwwAuthenticate)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+
+	var resourceMeta protectedResourceMetadata
+	if err := fetchJSON(ctx, a.client, resourceMetadataURL, &resourceMeta); err != nil {
+		return "", trace.Wrap(err, "fetching protected resource metadata")
+	}
+	if len(resourceMeta.AuthorizationServers) == 0 {
+		return "", trace.BadParameter("protected resource metadata lists no authorization servers")
+	}
+
+	meta, err := a.fetchAuthServerMetadata(ctx, resourceMeta.AuthorizationServers[0])
+	if err != nil {
+		return "", trace.Wrap(err, "fetching authorization server metadata")
+	}
+
+	reg, err := a.clientRegistration(ctx, meta)
+	if err != nil {
+		return "", trace.Wrap(err, "registering OAuth client")
+	}
+
+	tok, err := a.runAuthorizationCodeFlow(ctx, meta, reg)
+	if err != nil {
+		return "", trace.Wrap(err, "running authorization code flow")
+	}
+
+	if err := a.cfg.TokenStore.PutToken(a.resource, tok); err != nil {
+		a.cfg.Logger.WarnContext(ctx, "Failed to cache OAuth token", "error", err)
+	}
+	return tok.AuthorizationHeader(), nil
+}
+
+// refresh exchanges refreshToken for a new access token, using the
+// authorization server metadata and client registration discovered by the
+// most recent call to authorize.
+func (a *oauthAuthorizer) refresh(ctx context.Context, refreshToken string) (*OAuthToken, error) {
+	a.mu.Lock()
+	meta, reg := a.authMeta, a.registration
+	a.mu.Unlock()
+	if meta == nil || reg == nil {
+		return nil, trace.BadParameter("no authorization server known yet; run the interactive flow first")
+	}
+
+	tok, err := a.requestToken(ctx, reg, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {reg.ClientID},
+	}, meta.TokenEndpoint)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if tok.RefreshToken == "" {
+		tok.RefreshToken = refreshToken
+	}
+	if err := a.cfg.TokenStore.PutToken(a.resource, tok); err != nil {
+		a.cfg.Logger.WarnContext(ctx, "Failed to cache refreshed OAuth token", "error", err)
+	}
+	return tok, nil
+}
+
+// parseBearerChallenge extracts the resource_metadata URL from a
+// WWW-Authenticate: Bearer ... header.
+func parseBearerChallenge(wwwAuthenticate string) (string, error) {
+	const prefix = "Bearer "
+	if !strings.HasPrefix(wwwAuthenticate, prefix) {
+		return "", trace.BadParameter("unsupported WWW-Authenticate challenge: %q", wwwAuthenticate)
+	}
+	for _, param := range strings.Split(strings.TrimPrefix(wwwAuthenticate, prefix), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || strings.TrimSpace(name) != "resource_metadata" {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(value), `"`), nil
+	}
+	return "", trace.BadParameter("WWW-Authenticate challenge is missing resource_metadata: %q", wwwAuthenticate)
+}
+
+func (a *oauthAuthorizer) fetchAuthServerMetadata(ctx context.Context, issuer string) (*authServerMetadata, error) {
+	issuerURL, err := url.Parse(issuer)
+	if err != nil {
+		return nil, trace.Wrap(err, "parsing authorization server issuer")
+	}
+
+	var meta authServerMetadata
+	if err := fetchJSON(ctx, a.client, issuerURL.JoinPath(authServerMetadataPath).String(), &meta); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	a.mu.Lock()
+	a.authMeta = &meta
+	a.mu.Unlock()
+	return &meta, nil
+}
+
+func fetchJSON(ctx context.Context, client *http.Client, target string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return trace.BadParameter("%s returned %s", target, resp.Status)
+	}
+	return trace.Wrap(json.NewDecoder(resp.Body).Decode(out), "decoding response from %s", target)
+}
+
+// clientRegistration returns the cached dynamic client registration, or a
+// statically-configured client ID, registering a new client if neither is
+// available yet.
+func (a *oauthAuthorizer) clientRegistration(ctx context.Context, meta *authServerMetadata) (*clientRegistration, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.registration != nil {
+		return a.registration, nil
+	}
+	if a.cfg.ClientID != "" {
+		a.registration = &clientRegistration{ClientID: a.cfg.ClientID}
+		return a.registration, nil
+	}
+	if meta.RegistrationEndpoint == "" {
+		return nil, trace.BadParameter("authorization server does not support dynamic client registration; configure OAuthConfig.ClientID")
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"client_name":                a.cfg.ClientName,
+		"redirect_uris":              []string{"http://127.0.0.1/callback"},
+		"grant_types":                []string{"authorization_code", "refresh_token"},
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": "none",
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.RegistrationEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, trace.BadParameter("dynamic client registration returned %s", resp.Status)
+	}
+
+	var reg clientRegistration
+	if err := json.NewDecoder(resp.Body).Decode(&reg); err != nil {
+		return nil, trace.Wrap(err, "decoding dynamic client registration response")
+	}
+	a.registration = &reg
+	return a.registration, nil
+}
+
+// runAuthorizationCodeFlow performs the interactive authorization-code +
+// PKCE flow: it opens a loopback listener to receive the redirect, sends
+// the user to the authorization endpoint via cfg.BrowserOpener, and
+// exchanges the resulting code for a token.
+func (a *oauthAuthorizer) runAuthorizationCodeFlow(ctx context.Context, meta *authServerMetadata, reg *clientRegistration) (*OAuthToken, error) {
+	verifier, challenge, err := generatePKCEPair()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	state, err := randomURLSafeString(16)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	callback, err := newOAuthLoopbackCallback(state)
+	if err != nil {
+		return nil, trace.Wrap(err, "starting loopback listener for OAuth callback")
+	}
+	defer callback.Close()
+
+	authorizeURL, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		return nil, trace.Wrap(err, "parsing authorization endpoint")
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {reg.ClientID},
+		"redirect_uri":          {callback.redirectURI},
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	if len(a.cfg.Scopes) > 0 {
+		q.Set("scope", strings.Join(a.cfg.Scopes, " "))
+	}
+	authorizeURL.RawQuery = q.Encode()
+
+	if err := a.cfg.BrowserOpener.OpenURL(authorizeURL.String()); err != nil {
+		return nil, trace.Wrap(err, "opening browser for authorization")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.cfg.CallbackTimeout)
+	defer cancel()
+
+	code, err := callback.wait(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.requestToken(ctx, reg, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {callback.redirectURI},
+		"client_id":     {reg.ClientID},
+		"code_verifier": {verifier},
+	}, meta.TokenEndpoint)
+}
+
+func (a *oauthAuthorizer) requestToken(ctx context.Context, reg *clientRegistration, form url.Values, tokenEndpoint string) (*OAuthToken, error) {
+	if reg.ClientSecret != "" {
+		form.Set("client_secret", reg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, trace.BadParameter("token endpoint returned %s", resp.Status)
+	}
+
+	var tr tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return nil, trace.Wrap(err, "decoding token response")
+	}
+
+	tokenType := tr.TokenType
+	if tokenType == "" {
+		tokenType = "Bearer"
+	}
+	tok := &OAuthToken{
+		AccessToken:  tr.AccessToken,
+		RefreshToken: tr.RefreshToken,
+		TokenType:    tokenType,
+	}
+	if tr.ExpiresIn > 0 {
+		tok.ExpiresAt = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
+
+// generatePKCEPair returns a random code_verifier and its S256
+// code_challenge, per RFC 7636.
+func generatePKCEPair() (verifier, challenge string, err error) {
+	verifier, err = randomURLSafeString(32)
+	if err != nil {
+		return "", "", trace.Wrap(err)
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	challenge = base64.RawURLEncoding.EncodeToString(sum[:])
+	return verifier, challenge, nil
+}
+
+func randomURLSafeString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// oauthLoopbackCallback is a short-lived HTTP server on 127.0.0.1 that
+// receives the authorization server's redirect and hands the resulting code
+// (or error) back to the caller of wait.
+type oauthLoopbackCallback struct {
+	listener    net.Listener
+	server      *http.Server
+	redirectURI string
+	state       string
+	resultC     chan oauthCallbackResult
+}
+
+type oauthCallbackResult struct {
+	code string
+	err  error
+}
+
+// newOAuthLoopbackCallback starts a loopback HTTP server to receive the
+// authorization server's redirect. state is the value sent as the
+// authorize request's "state" parameter; a redirect whose own state
+// parameter doesn't match is rejected as a possible CSRF attempt.
+func newOAuthLoopbackCallback(state string) (*oauthLoopbackCallback, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	callback := &oauthLoopbackCallback{
+		listener:    listener,
+		redirectURI: fmt.Sprintf("http://%s/callback", listener.Addr().String()),
+		state:       state,
+		resultC:     make(chan oauthCallbackResult, 1),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", callback.handle)
+	callback.server = &http.Server{Handler: mux}
+
+	go callback.server.Serve(listener)
+	return callback, nil
+}
+
+func (c *oauthLoopbackCallback) handle(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	switch {
+	case q.Get("error") != "":
+		c.resultC <- oauthCallbackResult{err: trace.BadParameter("authorization server returned error: %s", q.Get("error"))}
+	case q.Get("state") != c.state:
+		c.resultC <- oauthCallbackResult{err: trace.BadParameter("authorization server redirect has a mismatched state parameter")}
+	default:
+		c.resultC <- oauthCallbackResult{code: q.Get("code")}
+	}
+	fmt.Fprint(w, "You may close this window and return to the application.")
+}
+
+// wait blocks until the loopback server receives a callback or ctx is done.
+func (c *oauthLoopbackCallback) wait(ctx context.Context) (string, error) {
+	select {
+	case result := <-c.resultC:
+		if result.err != nil {
+			return "", trace.Wrap(result.err)
+		}
+		if result.code == "" {
+			return "", trace.BadParameter("authorization server redirect is missing a code")
+		}
+		return result.code, nil
+	case <-ctx.Done():
+		return "", trace.Wrap(ctx.Err(), "timed out waiting for OAuth authorization")
+	}
+}
+
+func (c *oauthLoopbackCallback) Close() error {
+	return trace.Wrap(c.server.Close())
+}
@@ -0,0 +1,152 @@
+/*
+ * Teleport
+ * Copyright (C) 2025  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mcputils
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/observability/metrics"
+)
+
+// TransportDirection indicates whether an observed message was read from or
+// written to an MCP transport connection.
+type TransportDirection string
+
+const (
+	// TransportDirectionInbound is a message read from the remote MCP server.
+	TransportDirectionInbound TransportDirection = "inbound"
+	// TransportDirectionOutbound is a message written to the remote MCP
+	// server.
+	TransportDirectionOutbound TransportDirection = "outbound"
+)
+
+// TransportObserverEvent describes a single JSON-RPC message read from or
+// written to an MCP transport connection.
+type TransportObserverEvent struct {
+	// Direction is whether the message was read or written.
+	Direction TransportDirection
+	// SessionID is the remote server's session ID for the connection, if
+	// any.
+	SessionID string
+	// RawMessage is the raw JSON-RPC message, as read from or written to the
+	// wire.
+	RawMessage string
+	// Method is the JSON-RPC "method" field, best-effort parsed from
+	// RawMessage. Empty for responses, which don't carry a method.
+	Method string
+	// Latency is how long the read or write took.
+	Latency time.Duration
+	// Err is set if the read or write failed.
+	Err error
+}
+
+// TransportObserver is notified of every JSON-RPC message read from or
+// written to an MCP transport connection. Implementations run inline on the
+// read/write path and must return promptly.
+type TransportObserver interface {
+	ObserveTransportMessage(ctx context.Context, event TransportObserverEvent)
+}
+
+// notifyTransportObservers notifies every observer in observers of event.
+func notifyTransportObservers(ctx context.Context, observers []TransportObserver, event TransportObserverEvent) {
+	for _, observer := range observers {
+		observer.ObserveTransportMessage(ctx, event)
+	}
+}
+
+// parseJSONRPCMethod best-effort extracts the "method" field from a raw
+// JSON-RPC message, so observers can tag metrics and events by method
+// without fully parsing the message themselves. Returns "" for responses
+// (which don't carry a method) or malformed input.
+func parseJSONRPCMethod(rawMessage string) string {
+	var base struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal([]byte(rawMessage), &base); err != nil {
+		return ""
+	}
+	return base.Method
+}
+
+// MetricsObserver is a TransportObserver that exports Prometheus metrics for
+// MCP transport traffic: a per-method message counter and a per-method
+// latency histogram. It also registers an in-flight gauge, which
+// ObserveTransportMessage does not update; the gauge only moves through
+// trackInFlight, and no transport calls it, so it stays at zero. It does not
+// emit Teleport audit events; those are already recorded at the session
+// layer by lib/srv/mcp's auditor.
+type MetricsObserver struct {
+	messages *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+}
+
+// NewMetricsObserver creates a MetricsObserver and registers its collectors
+// with the default Prometheus registry.
+func NewMetricsObserver() (*MetricsObserver, error) {
+	o := &MetricsObserver{
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: teleport.MetricNamespace,
+			Subsystem: "mcp",
+			Name:      "transport_messages_total",
+			Help:      "Number of MCP JSON-RPC messages observed on the transport, by direction, method, and outcome.",
+		}, []string{"direction", "method", "success"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: teleport.MetricNamespace,
+			Subsystem: "mcp",
+			Name:      "transport_message_latency_seconds",
+			Help:      "Latency of MCP JSON-RPC transport reads and writes, by direction and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"direction", "method"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: teleport.MetricNamespace,
+			Subsystem: "mcp",
+			Name:      "transport_messages_in_flight",
+			Help:      "Number of MCP JSON-RPC transport reads and writes currently in progress.",
+		}),
+	}
+	if err := metrics.RegisterPrometheusCollectors(o.messages, o.latency, o.inFlight); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o, nil
+}
+
+// ObserveTransportMessage implements TransportObserver.
+func (o *MetricsObserver) ObserveTransportMessage(_ context.Context, event TransportObserverEvent) {
+	success := "true"
+	if event.Err != nil {
+		success = "false"
+	}
+	o.messages.WithLabelValues(string(event.Direction), event.Method, success).Inc()
+	o.latency.WithLabelValues(string(event.Direction), event.Method).Observe(event.Latency.Seconds())
+}
+
+// trackInFlight increments the in-flight gauge and returns a func that
+// decrements it, for use as `defer observer.trackInFlight()()`.
+func (o *MetricsObserver) trackInFlight() func() {
+	o.inFlight.Inc()
+	return o.inFlight.Dec
+}
@@ -23,14 +23,22 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"log/slog"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gravitational/trace"
 	"github.com/mark3labs/mcp-go/mcp"
 
+	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/defaults"
 )
 
@@ -38,6 +46,15 @@ import (
 type SSERequestWriter struct {
 	client      *http.Client
 	endpointURL *url.URL
+
+	// oauth drives the MCP OAuth 2.1 flow when set, injecting an
+	// Authorization header into every request and re-authorizing once on a
+	// 401 challenge. Nil if ConnectSSEServer wasn't given an OAuthConfig.
+	oauth *oauthAuthorizer
+
+	// observers are notified of every message written, for audit/metrics
+	// purposes. Set by ConnectSSEServer.
+	observers []TransportObserver
 }
 
 // NewSSERequestWriter creates a new SSERequestWriter.
@@ -53,36 +70,121 @@ func (w *SSERequestWriter) GetSessionID() string {
 	return w.endpointURL.Query().Get("sessionId")
 }
 
-// WriteMessage posts the request to the remote server.
-func (w *SSERequestWriter) WriteMessage(ctx context.Context, msg mcp.JSONRPCMessage) error {
+// WriteMessage posts the request to the remote server, authorizing and
+// retrying once if the server challenges the request with a 401.
+func (w *SSERequestWriter) WriteMessage(ctx context.Context, msg mcp.JSONRPCMessage) (err error) {
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return trace.Wrap(err, "marshalling message")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpointURL.String(), bytes.NewReader(data))
+	start := time.Now()
+	defer func() {
+		notifyTransportObservers(ctx, w.observers, TransportObserverEvent{
+			Direction:  TransportDirectionOutbound,
+			SessionID:  w.GetSessionID(),
+			RawMessage: string(data),
+			Method:     parseJSONRPCMethod(string(data)),
+			Latency:    time.Since(start),
+			Err:        err,
+		})
+	}()
+
+	authHeader, err := w.cachedAuthorizationHeader(ctx)
 	if err != nil {
-		return trace.Wrap(err, "building SSE POST request")
+		return trace.Wrap(err)
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := w.client.Do(req)
+	resp, err := w.post(ctx, data, authHeader)
 	if err != nil {
 		return trace.Wrap(err, "sending SSE request")
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized && w.oauth != nil {
+		authHeader, err = w.oauth.authorize(ctx, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return trace.Wrap(err, "authorizing SSE request")
+		}
+		resp.Body.Close()
+		resp, err = w.post(ctx, data, authHeader)
+		if err != nil {
+			return trace.Wrap(err, "sending SSE request")
+		}
+		defer resp.Body.Close()
+	}
+
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
 		return trace.BadParameter("SSE request returned %s", resp.Status)
 	}
 	return nil
 }
 
+// cachedAuthorizationHeader returns the Authorization header value for a
+// previously-cached OAuth token, or "" if OAuth isn't configured or there's
+// no token cached yet.
+func (w *SSERequestWriter) cachedAuthorizationHeader(ctx context.Context) (string, error) {
+	if w.oauth == nil {
+		return "", nil
+	}
+	header, err := w.oauth.authorizationHeader(ctx)
+	return header, trace.Wrap(err)
+}
+
+// post builds and sends the SSE POST request, setting authHeader as the
+// Authorization header if non-empty.
+func (w *SSERequestWriter) post(ctx context.Context, data []byte, authHeader string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpointURL.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, trace.Wrap(err, "building SSE POST request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	return w.client.Do(req)
+}
+
+const (
+	// defaultSSEReconnectRetry is the backoff used to reconnect a dropped SSE
+	// stream when the server hasn't sent a "retry:" hint.
+	defaultSSEReconnectRetry = time.Second
+	// maxSSEReconnectRetry caps the exponential backoff between reconnects.
+	maxSSEReconnectRetry = 30 * time.Second
+	// maxSSEReconnectAttempts bounds how many times in a row ReadMessage will
+	// reconnect a dropped stream before giving up and returning the error to
+	// the caller.
+	maxSSEReconnectAttempts = 10
+)
+
 // SSEResponseReader implements TransportReader for reading SSE stream from the
-// MCP server.
+// MCP server. ReadMessage transparently reconnects a dropped stream, resuming
+// from the last event ID it saw via Last-Event-ID, so transient network
+// hiccups don't kill the enclosing MCP session.
 type SSEResponseReader struct {
 	io.Closer
 	br *bufio.Reader
+
+	// client and baseURL are used to re-issue the SSE GET on reconnect. Nil
+	// baseURL disables reconnecting, so a stream break is reported to the
+	// caller as an error instead.
+	client  *http.Client
+	baseURL *url.URL
+	// authHeader returns the Authorization header to use when reconnecting,
+	// if OAuth is configured. May be nil.
+	authHeader func(ctx context.Context) (string, error)
+	logger     *slog.Logger
+
+	// sessionID is the remote server's session ID, set once it's known from
+	// the endpoint event. Used to tag observer events.
+	sessionID string
+	// observers are notified of every message read, for audit/metrics
+	// purposes. Set by ConnectSSEServer.
+	observers []TransportObserver
+
+	mu               sync.Mutex
+	lastEventID      string
+	retry            time.Duration
+	reconnectAttempt int
 }
 
 // NewSSEResponseReader creates a new SSEResponseReader. Input reader is usually the
@@ -91,6 +193,7 @@ func NewSSEResponseReader(reader io.ReadCloser) *SSEResponseReader {
 	return &SSEResponseReader{
 		Closer: reader,
 		br:     bufio.NewReader(reader),
+		logger: slog.With(teleport.ComponentKey, "mcp"),
 	}
 }
 
@@ -112,16 +215,161 @@ func (r *SSEResponseReader) ReadEndpoint(ctx context.Context, baseURL *url.URL)
 	return endpointURI, nil
 }
 
-// ReadMessage reads the next SSE message event from SSE stream.
+// ReadMessage reads the next SSE message event from SSE stream, transparently
+// reconnecting the stream if it drops and reconnecting is enabled.
 func (r *SSEResponseReader) ReadMessage(ctx context.Context) (string, error) {
-	event, err := readSSEEvent(ctx, r.br)
+	for {
+		start := time.Now()
+		event, err := readSSEEvent(ctx, r.br)
+		latency := time.Since(start)
+		if err != nil {
+			notifyTransportObservers(ctx, r.observers, TransportObserverEvent{
+				Direction: TransportDirectionInbound,
+				SessionID: r.sessionID,
+				Latency:   latency,
+				Err:       err,
+			})
+			if !r.shouldReconnect(ctx, err) {
+				return "", trace.Wrap(err)
+			}
+			if err := r.reconnect(ctx); err != nil {
+				return "", trace.Wrap(err)
+			}
+			continue
+		}
+
+		r.recordEvent(event)
+		if event.EventType != SSEEventMessage {
+			return "", newReaderParseError(trace.BadParameter("unexpected event type %s", event.EventType))
+		}
+
+		notifyTransportObservers(ctx, r.observers, TransportObserverEvent{
+			Direction:  TransportDirectionInbound,
+			SessionID:  r.sessionID,
+			RawMessage: event.Data,
+			Method:     parseJSONRPCMethod(event.Data),
+			Latency:    latency,
+		})
+
+		r.mu.Lock()
+		r.reconnectAttempt = 0
+		r.mu.Unlock()
+		return event.Data, nil
+	}
+}
+
+// shouldReconnect reports whether err, seen while reading the SSE stream,
+// should trigger a reconnect rather than being returned to the caller.
+// Reconnecting requires the reader to have been set up with a baseURL
+// (ConnectSSEServer does this), and the error must look like a dropped
+// connection rather than a cancellation or a malformed stream.
+func (r *SSEResponseReader) shouldReconnect(ctx context.Context, err error) bool {
+	if r.baseURL == nil || ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// recordEvent updates the last seen event ID and reconnect retry hint from
+// every event ReadMessage reads, not just "message" events. The endpoint
+// event read by ReadEndpoint is not recorded, and readSSEEvent drops blocks
+// that carry no data, so an id or retry field on such a block is not seen.
+func (r *SSEResponseReader) recordEvent(event *SSEEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if event.ID != "" {
+		r.lastEventID = event.ID
+	}
+	if event.Retry > 0 {
+		r.retry = event.Retry
+	}
+}
+
+// reconnect re-issues the SSE GET request, resuming from the last event ID
+// seen via the Last-Event-ID header, backing off exponentially between
+// attempts and giving up after maxSSEReconnectAttempts in a row.
+func (r *SSEResponseReader) reconnect(ctx context.Context) error {
+	r.mu.Lock()
+	r.reconnectAttempt++
+	attempt := r.reconnectAttempt
+	lastEventID := r.lastEventID
+	retryHint := r.retry
+	r.mu.Unlock()
+
+	if attempt > maxSSEReconnectAttempts {
+		return trace.LimitExceeded("SSE stream dropped and failed to reconnect after %d attempts", maxSSEReconnectAttempts)
+	}
+
+	backoff := reconnectBackoff(retryHint, attempt)
+	r.logger.WarnContext(ctx, "SSE stream dropped, reconnecting", "attempt", attempt, "backoff", backoff, "last_event_id", lastEventID)
+
+	select {
+	case <-time.After(backoff):
+	case <-ctx.Done():
+		return trace.Wrap(ctx.Err())
+	}
+
+	authHeader := ""
+	if r.authHeader != nil {
+		var err error
+		authHeader, err = r.authHeader(ctx)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	req, err := makeSSEConnectionRequest(ctx, r.baseURL.String())
 	if err != nil {
-		return "", trace.Wrap(err)
+		return trace.Wrap(err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return trace.Wrap(err, "reconnecting SSE stream")
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return trace.Errorf("reconnecting SSE stream returned unexpected status code: %d", resp.StatusCode)
+	}
+
+	r.Closer.Close()
+	r.Closer = resp.Body
+	r.br = bufio.NewReader(resp.Body)
+	return nil
+}
+
+// reconnectBackoff computes the delay before the next reconnect attempt,
+// using the server's "retry:" hint as the base interval if one was seen
+// (falling back to defaultSSEReconnectRetry otherwise), doubling per attempt,
+// capped at maxSSEReconnectRetry, and jittered to avoid a thundering herd of
+// clients reconnecting in lockstep.
+func reconnectBackoff(retryHint time.Duration, attempt int) time.Duration {
+	base := retryHint
+	if base <= 0 {
+		base = defaultSSEReconnectRetry
 	}
-	if event.EventType != SSEEventMessage {
-		return "", newReaderParseError(trace.BadParameter("unexpected event type %s", event.EventType))
+
+	backoff := base
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= maxSSEReconnectRetry {
+			backoff = maxSSEReconnectRetry
+			break
+		}
 	}
-	return event.Data, nil
+
+	jitter := time.Duration(rand.Int63n(int64(backoff) / 2))
+	return backoff/2 + jitter
 }
 
 // Type returns "sse".
@@ -129,36 +377,87 @@ func (r *SSEResponseReader) Type() string {
 	return "sse"
 }
 
-func ConnectSSEServer(ctx context.Context, baseURL *url.URL) (*SSEResponseReader, *SSERequestWriter, error) {
+// ConnectSSEServer connects to an MCP server speaking the legacy SSE-over-HTTP
+// transport at baseURL. oauthCfg enables the MCP OAuth 2.1 authorization
+// flow for servers that challenge the connection with a
+// "WWW-Authenticate: Bearer" 401; pass nil to connect unauthenticated.
+// observers, if any, are notified of every message read from or written to
+// the connection.
+func ConnectSSEServer(ctx context.Context, baseURL *url.URL, oauthCfg *OAuthConfig, observers ...TransportObserver) (*SSEResponseReader, *SSERequestWriter, error) {
 	client, err := defaults.HTTPClient()
 	if err != nil {
 		return nil, nil, trace.Wrap(err, "making HTTP client")
 	}
 
-	connectReq, err := makeSSEConnectionRequest(ctx, baseURL.String())
-	if err != nil {
-		return nil, nil, trace.Wrap(err, "making SSE connection request")
+	var oauth *oauthAuthorizer
+	if oauthCfg != nil {
+		if err := oauthCfg.CheckAndSetDefaults(); err != nil {
+			return nil, nil, trace.Wrap(err)
+		}
+		oauth = newOAuthAuthorizer(client, baseURL, *oauthCfg)
 	}
 
-	resp, err := client.Do(connectReq)
+	authHeader := ""
+	if oauth != nil {
+		authHeader, err = oauth.authorizationHeader(ctx)
+		if err != nil {
+			return nil, nil, trace.Wrap(err)
+		}
+	}
+
+	resp, err := connectSSE(ctx, client, baseURL, authHeader)
 	if err != nil {
 		return nil, nil, trace.Wrap(err, "sending SSE request")
 	}
+
+	if resp.StatusCode == http.StatusUnauthorized && oauth != nil {
+		authHeader, err = oauth.authorize(ctx, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			resp.Body.Close()
+			return nil, nil, trace.Wrap(err, "authorizing SSE connection")
+		}
+		resp.Body.Close()
+		resp, err = connectSSE(ctx, client, baseURL, authHeader)
+		if err != nil {
+			return nil, nil, trace.Wrap(err, "sending SSE request")
+		}
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		defer resp.Body.Close()
 		return nil, nil, trace.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
 	reader := NewSSEResponseReader(resp.Body)
+	reader.client = client
+	reader.baseURL = baseURL
+	reader.observers = observers
+	if oauth != nil {
+		reader.authHeader = oauth.authorizationHeader
+	}
 	endpointURL, err := reader.ReadEndpoint(ctx, baseURL)
 	if err != nil {
 		defer reader.Close()
 		return nil, nil, trace.Wrap(err, "reading SSE server endpoint")
 	}
+	reader.sessionID = endpointURL.Query().Get("sessionId")
 	requestWriter := NewSSERequestWriter(client, endpointURL)
+	requestWriter.oauth = oauth
+	requestWriter.observers = observers
 	return reader, requestWriter, nil
 }
 
+func connectSSE(ctx context.Context, client *http.Client, baseURL *url.URL, authHeader string) (*http.Response, error) {
+	req, err := makeSSEConnectionRequest(ctx, baseURL.String())
+	if err != nil {
+		return nil, trace.Wrap(err, "making SSE connection request")
+	}
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	return client.Do(req)
+}
+
 func makeSSEConnectionRequest(ctx context.Context, url string) (*http.Request, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -191,10 +490,19 @@ func toSSEEventType(event string) (SSEEventType, error) {
 type SSEEvent struct {
 	EventType SSEEventType
 	Data      string
+	// ID is the event's "id:" field, if any. Clients that support
+	// resumability echo the last ID they saw back in a Last-Event-ID header
+	// when reconnecting.
+	ID string
+	// Retry is the event's "retry:" field, if any, in milliseconds. Servers
+	// use it to hint how long a client should wait before reconnecting a
+	// dropped stream.
+	Retry time.Duration
 }
 
 func readSSEEvent(ctx context.Context, br *bufio.Reader) (*SSEEvent, error) {
-	var event, data string
+	var event, data, id string
+	var retry time.Duration
 	for {
 		if ctx.Err() != nil {
 			return nil, trace.Wrap(ctx.Err())
@@ -215,7 +523,7 @@ func readSSEEvent(ctx context.Context, br *bufio.Reader) (*SSEEvent, error) {
 				if err != nil {
 					return nil, newReaderParseError(err)
 				}
-				return &SSEEvent{EventType: eventType, Data: data}, nil
+				return &SSEEvent{EventType: eventType, Data: data, ID: id, Retry: retry}, nil
 			}
 			continue
 		}
@@ -224,6 +532,12 @@ func readSSEEvent(ctx context.Context, br *bufio.Reader) (*SSEEvent, error) {
 			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
 		} else if strings.HasPrefix(line, "data:") {
 			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		} else if strings.HasPrefix(line, "id:") {
+			id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		} else if strings.HasPrefix(line, "retry:") {
+			if ms, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "retry:"))); err == nil {
+				retry = time.Duration(ms) * time.Millisecond
+			}
 		}
 	}
 }
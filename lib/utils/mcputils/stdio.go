@@ -26,6 +26,9 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gravitational/trace"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -34,6 +37,11 @@ import (
 	logutils "github.com/gravitational/teleport/lib/utils/log"
 )
 
+// defaultRequestTimeout is how long MessageReader waits for a request it
+// dispatched to OnRequest to be answered before synthesizing a timeout
+// error through OnParseError.
+const defaultRequestTimeout = 30 * time.Second
+
 // StderrTraceLogWriter implements io.Writer and logs the content at TRACE
 // level. Used for tracing stderr.
 type StderrTraceLogWriter struct {
@@ -166,6 +174,16 @@ type MessageReaderConfig struct {
 	// OnNotification specifies the handler for handling notification. Any error
 	// returned by the handler stops this message reader.
 	OnNotification HandleNotificationFunc
+
+	// Writer is an optional MessageWriter used to emit a single combined
+	// JSON array when every response to a batched ([]...) request has been
+	// correlated. If unset, those responses are delivered to OnResponse one
+	// at a time instead.
+	Writer MessageWriter
+	// RequestTimeout bounds how long a request dispatched to OnRequest may
+	// wait for its correlated response before a JSONRPCError is synthesized
+	// through OnParseError. Defaults to defaultRequestTimeout.
+	RequestTimeout time.Duration
 }
 
 // CheckAndSetDefaults checks values and sets defaults.
@@ -188,12 +206,34 @@ func (c *MessageReaderConfig) CheckAndSetDefaults() error {
 	if c.Logger == nil {
 		c.Logger = slog.With(teleport.ComponentKey, "mcp")
 	}
+	if c.RequestTimeout <= 0 {
+		c.RequestTimeout = defaultRequestTimeout
+	}
 	return nil
 }
 
+// pendingBatch tracks the responses still outstanding for a batched
+// ([]...) request, so they can be emitted together once every member has
+// been correlated with its response.
+type pendingBatch struct {
+	remaining int
+	responses []mcp.JSONRPCMessage
+}
+
+// pendingRequest tracks a request dispatched to OnRequest that's still
+// waiting for its correlated response.
+type pendingRequest struct {
+	id    mcp.RequestId
+	batch *pendingBatch
+	timer *time.Timer
+}
+
 // MessageReader reads messages with provided transport and config.
 type MessageReader struct {
 	cfg MessageReaderConfig
+
+	mu      sync.Mutex
+	pending map[string]*pendingRequest
 }
 
 // NewMessageReader creates a new MessageReader. Must call "Start" to
@@ -203,7 +243,8 @@ func NewMessageReader(cfg MessageReaderConfig) (*MessageReader, error) {
 		return nil, trace.Wrap(err)
 	}
 	return &MessageReader{
-		cfg: cfg,
+		cfg:     cfg,
+		pending: make(map[string]*pendingRequest),
 	}, nil
 }
 
@@ -223,6 +264,8 @@ func (r *MessageReader) Run(ctx context.Context) {
 	case <-ctx.Done():
 	}
 
+	r.stopPendingRequests()
+
 	r.cfg.Logger.InfoContext(r.cfg.ParentContext, "Finished processing messages", "transport", r.cfg.Transport.Type())
 	if err := r.cfg.Transport.Close(); err != nil && !IsOKCloseError(err) {
 		r.cfg.Logger.ErrorContext(r.cfg.ParentContext, "Failed to close transport", "error", err)
@@ -261,31 +304,69 @@ func (r *MessageReader) processNextMessage(ctx context.Context) error {
 
 	r.cfg.Logger.Log(ctx, logutils.TraceLevel, "Trace read", "raw", rawMessage)
 
+	// The JSON-RPC 2.0 spec allows a request or response to be a batch, i.e.
+	// a top-level JSON array instead of a single object.
+	if strings.HasPrefix(strings.TrimSpace(rawMessage), "[") {
+		return trace.Wrap(r.processBatchMessage(ctx, rawMessage), "handling batch message")
+	}
+
 	var base baseJSONRPCMessage
 	if parseError := json.Unmarshal([]byte(rawMessage), &base); parseError != nil {
 		rpcError := mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.PARSE_ERROR, parseError.Error(), nil)
-		if err := r.cfg.OnParseError(ctx, &rpcError); err != nil {
-			return trace.Wrap(err, "handling JSON unmarshal error")
+		return trace.Wrap(r.cfg.OnParseError(ctx, &rpcError), "handling JSON unmarshal error")
+	}
+	return trace.Wrap(r.dispatchMessage(ctx, base, rawMessage, nil), "handling message")
+}
+
+// processBatchMessage unmarshals a batched ([]...) message and dispatches
+// each element individually, tracking the requests among them as a single
+// pendingBatch so their responses can later be re-assembled into one
+// combined array.
+func (r *MessageReader) processBatchMessage(ctx context.Context, rawMessage string) error {
+	var elements []baseJSONRPCMessage
+	if parseError := json.Unmarshal([]byte(rawMessage), &elements); parseError != nil {
+		rpcError := mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.PARSE_ERROR, parseError.Error(), nil)
+		return trace.Wrap(r.cfg.OnParseError(ctx, &rpcError), "handling JSON unmarshal error")
+	}
+
+	var batch *pendingBatch
+	for _, base := range elements {
+		if base.isRequest() {
+			if batch == nil {
+				batch = &pendingBatch{}
+			}
+			batch.remaining++
 		}
 	}
 
+	for _, base := range elements {
+		if err := r.dispatchMessage(ctx, base, rawMessage, batch); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// dispatchMessage routes a single JSON-RPC message (request, response, or
+// notification) to its configured handler. batch is non-nil when this
+// message was read as part of a batched ([]...) message.
+func (r *MessageReader) dispatchMessage(ctx context.Context, base baseJSONRPCMessage, rawMessage string, batch *pendingBatch) error {
 	switch {
 	case base.isNotification():
 		return trace.Wrap(r.cfg.OnNotification(ctx, base.makeNotification()), "handling notification")
+
 	case base.isRequest():
-		if r.cfg.OnRequest != nil {
-			return trace.Wrap(r.cfg.OnRequest(ctx, base.makeRequest()), "handling request")
+		if r.cfg.OnRequest == nil {
+			// Should not happen. Log something just in case.
+			r.cfg.Logger.DebugContext(ctx, "Skipping request", "id", base.ID)
+			return nil
 		}
-		// Should not happen. Log something just in case.
-		r.cfg.Logger.DebugContext(ctx, "Skipping request", "id", base.ID)
-		return nil
+		r.trackPendingRequest(ctx, base.ID, batch)
+		return trace.Wrap(r.cfg.OnRequest(ctx, base.makeRequest()), "handling request")
+
 	case base.isResponse():
-		if r.cfg.OnResponse != nil {
-			return trace.Wrap(r.cfg.OnResponse(ctx, base.makeResponse()), "handling response")
-		}
-		// Should not happen. Log something just in case.
-		r.cfg.Logger.DebugContext(ctx, "Skipping response", "id", base.ID)
-		return nil
+		return trace.Wrap(r.correlateResponse(ctx, base), "handling response")
+
 	default:
 		rpcError := mcp.NewJSONRPCError(base.ID, mcp.PARSE_ERROR, "unknown message type", rawMessage)
 		return trace.Wrap(
@@ -294,3 +375,127 @@ func (r *MessageReader) processNextMessage(ctx context.Context) error {
 		)
 	}
 }
+
+// trackPendingRequest records id as awaiting a response, scheduling a
+// timeout that synthesizes a JSONRPCError through OnParseError if no
+// response shows up in time.
+func (r *MessageReader) trackPendingRequest(ctx context.Context, id mcp.RequestId, batch *pendingBatch) {
+	key := requestIDKey(id)
+
+	r.mu.Lock()
+	if old, ok := r.pending[key]; ok {
+		old.timer.Stop()
+	}
+	r.mu.Unlock()
+
+	pending := &pendingRequest{id: id, batch: batch}
+	pending.timer = time.AfterFunc(r.cfg.RequestTimeout, func() {
+		r.expirePendingRequest(ctx, key)
+	})
+
+	r.mu.Lock()
+	r.pending[key] = pending
+	r.mu.Unlock()
+}
+
+// expirePendingRequest fires when a tracked request's timeout elapses
+// before a response was correlated to it.
+func (r *MessageReader) expirePendingRequest(ctx context.Context, key string) {
+	r.mu.Lock()
+	pending, ok := r.pending[key]
+	if ok {
+		delete(r.pending, key)
+	}
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	rpcError := mcp.NewJSONRPCError(pending.id, mcp.INTERNAL_ERROR, "timed out waiting for a response", nil)
+	if err := r.cfg.OnParseError(ctx, &rpcError); err != nil {
+		r.cfg.Logger.ErrorContext(ctx, "Failed to handle request timeout", "error", err)
+	}
+}
+
+// correlateResponse matches an incoming response against the pending
+// request map. A response with no matching pending request synthesizes a
+// JSONRPCError through OnParseError instead of being delivered to
+// OnResponse.
+func (r *MessageReader) correlateResponse(ctx context.Context, base baseJSONRPCMessage) error {
+	key := requestIDKey(base.ID)
+
+	r.mu.Lock()
+	pending, ok := r.pending[key]
+	if ok {
+		delete(r.pending, key)
+	}
+	r.mu.Unlock()
+
+	if !ok {
+		rpcError := mcp.NewJSONRPCError(base.ID, mcp.PARSE_ERROR, "response does not match any pending request", nil)
+		return trace.Wrap(r.cfg.OnParseError(ctx, &rpcError), "handling unmatched response")
+	}
+	pending.timer.Stop()
+
+	if pending.batch == nil {
+		if r.cfg.OnResponse == nil {
+			// Should not happen. Log something just in case.
+			r.cfg.Logger.DebugContext(ctx, "Skipping response", "id", base.ID)
+			return nil
+		}
+		return trace.Wrap(r.cfg.OnResponse(ctx, base.makeResponse()), "handling response")
+	}
+	return trace.Wrap(r.completeBatchResponse(ctx, pending.batch, base.makeResponse()), "handling batched response")
+}
+
+// completeBatchResponse collects resp into batch and, once every request in
+// the batch has a correlated response, emits them together as a single
+// array through Writer (or, if unset, delivers them to OnResponse one at a
+// time).
+func (r *MessageReader) completeBatchResponse(ctx context.Context, batch *pendingBatch, resp *JSONRPCResponse) error {
+	r.mu.Lock()
+	batch.responses = append(batch.responses, resp)
+	batch.remaining--
+	done := batch.remaining <= 0
+	responses := batch.responses
+	r.mu.Unlock()
+
+	if !done {
+		return nil
+	}
+
+	if r.cfg.Writer == nil {
+		if r.cfg.OnResponse == nil {
+			// Should not happen. Log something just in case.
+			r.cfg.Logger.DebugContext(ctx, "Skipping batched responses")
+			return nil
+		}
+		for _, resp := range responses {
+			if err := r.cfg.OnResponse(ctx, resp.(*JSONRPCResponse)); err != nil {
+				return trace.Wrap(err, "handling batched response")
+			}
+		}
+		return nil
+	}
+	return trace.Wrap(r.cfg.Writer.WriteMessage(ctx, responses), "writing batched response")
+}
+
+// stopPendingRequests stops every outstanding request timeout, so none of
+// them fire after the reader has stopped.
+func (r *MessageReader) stopPendingRequests() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, pending := range r.pending {
+		pending.timer.Stop()
+		delete(r.pending, key)
+	}
+}
+
+// requestIDKey returns a comparable map key for a mcp.RequestId.
+func requestIDKey(id mcp.RequestId) string {
+	data, err := json.Marshal(id)
+	if err != nil {
+		return fmt.Sprintf("%v", id)
+	}
+	return string(data)
+}
@@ -0,0 +1,372 @@
+/*
+ * Teleport
+ * Copyright (C) 2025  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mcputils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/gravitational/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gravitational/teleport/lib/defaults"
+)
+
+// mcpSessionIDHeader is the header the Streamable HTTP transport uses to
+// correlate requests with a server-assigned session, per the MCP spec.
+const mcpSessionIDHeader = "Mcp-Session-Id"
+
+// lastEventIDHeader is the header used to resume a dropped SSE stream at the
+// last event the client saw.
+const lastEventIDHeader = "Last-Event-ID"
+
+// StreamableHTTPRequestWriter posts requests to a Streamable HTTP MCP
+// server. Each response - a single JSON object or an SSE stream of one or
+// more JSON-RPC messages - is decoded inline and fed to the paired
+// StreamableHTTPResponseReader's message channel.
+type StreamableHTTPRequestWriter struct {
+	client      *http.Client
+	endpointURL *url.URL
+	reader      *StreamableHTTPResponseReader
+
+	// oauth drives the MCP OAuth 2.1 flow when set, injecting an
+	// Authorization header into every request and re-authorizing once on a
+	// 401 challenge. Nil if ConnectStreamableHTTPServer wasn't given an
+	// OAuthConfig.
+	oauth *oauthAuthorizer
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+// GetSessionID returns the session ID assigned by the server, if any.
+func (w *StreamableHTTPRequestWriter) GetSessionID() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sessionID
+}
+
+// WriteMessage posts msg to the server and streams any resulting
+// response(s) to the paired StreamableHTTPResponseReader, authorizing and
+// retrying once if the server challenges the request with a 401.
+func (w *StreamableHTTPRequestWriter) WriteMessage(ctx context.Context, msg mcp.JSONRPCMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return trace.Wrap(err, "marshalling message")
+	}
+
+	authHeader := ""
+	if w.oauth != nil {
+		if authHeader, err = w.oauth.authorizationHeader(ctx); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	resp, err := w.post(ctx, data, authHeader)
+	if err != nil {
+		return trace.Wrap(err, "sending Streamable HTTP request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized && w.oauth != nil {
+		authHeader, err = w.oauth.authorize(ctx, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return trace.Wrap(err, "authorizing Streamable HTTP request")
+		}
+		resp.Body.Close()
+		resp, err = w.post(ctx, data, authHeader)
+		if err != nil {
+			return trace.Wrap(err, "sending Streamable HTTP request")
+		}
+		defer resp.Body.Close()
+	}
+
+	if sessionID := resp.Header.Get(mcpSessionIDHeader); sessionID != "" {
+		w.mu.Lock()
+		w.sessionID = sessionID
+		w.mu.Unlock()
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusAccepted:
+		// The server acknowledged a notification or response with no body.
+		return nil
+	default:
+		return trace.BadParameter("Streamable HTTP request returned %s", resp.Status)
+	}
+
+	switch contentType := resp.Header.Get("Content-Type"); {
+	case isEventStreamContentType(contentType):
+		return trace.Wrap(w.reader.consumeEventStream(ctx, resp.Body), "reading Streamable HTTP SSE response")
+	default:
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return trace.Wrap(err, "reading Streamable HTTP response body")
+		}
+		return trace.Wrap(w.reader.push(ctx, string(body)), "delivering Streamable HTTP response")
+	}
+}
+
+// post builds and sends the Streamable HTTP POST request, setting
+// authHeader as the Authorization header if non-empty.
+func (w *StreamableHTTPRequestWriter) post(ctx context.Context, data []byte, authHeader string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpointURL.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, trace.Wrap(err, "building Streamable HTTP POST request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if sessionID := w.GetSessionID(); sessionID != "" {
+		req.Header.Set(mcpSessionIDHeader, sessionID)
+	}
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	return w.client.Do(req)
+}
+
+func isEventStreamContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "text/event-stream"
+}
+
+// terminateSession asks the server to end the session, if one was assigned,
+// by sending a DELETE to the endpoint with the session's Mcp-Session-Id
+// header, per the Streamable HTTP session termination spec. Servers aren't
+// required to support explicit termination and are free to reject this with
+// 405, which isn't treated as an error.
+func (w *StreamableHTTPRequestWriter) terminateSession(ctx context.Context) error {
+	sessionID := w.GetSessionID()
+	if sessionID == "" {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, w.endpointURL.String(), nil)
+	if err != nil {
+		return trace.Wrap(err, "building Streamable HTTP DELETE request")
+	}
+	req.Header.Set(mcpSessionIDHeader, sessionID)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return trace.Wrap(err, "sending Streamable HTTP session termination request")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusMethodNotAllowed:
+		return nil
+	default:
+		return trace.BadParameter("Streamable HTTP session termination returned %s", resp.Status)
+	}
+}
+
+// StreamableHTTPResponseReader implements TransportReader for the
+// Streamable HTTP transport. Unlike the SSE transport, where every server
+// message arrives over a single long-lived GET, messages here can come from
+// the body of each POST response and, optionally, from a long-lived GET
+// used for server-initiated notifications - both are funneled through the
+// same channel so MessageReader.Run sees one ordered stream either way.
+type StreamableHTTPResponseReader struct {
+	client  *http.Client
+	baseURL *url.URL
+	writer  *StreamableHTTPRequestWriter
+
+	messages chan string
+	closeErr error
+	closeOne sync.Once
+
+	mu            sync.Mutex
+	lastEventID   string
+	notifyStopped chan struct{}
+}
+
+// ReadMessage returns the next message delivered by either a POST response
+// or the optional server-initiated GET stream.
+func (r *StreamableHTTPResponseReader) ReadMessage(ctx context.Context) (string, error) {
+	select {
+	case msg, ok := <-r.messages:
+		if !ok {
+			return "", trace.Wrap(r.closeErr)
+		}
+		return msg, nil
+	case <-ctx.Done():
+		return "", trace.Wrap(ctx.Err())
+	}
+}
+
+// Type returns "streamable_http".
+func (r *StreamableHTTPResponseReader) Type() string {
+	return "streamable_http"
+}
+
+// Close stops the background notification stream, if running, asks the
+// server to terminate the session, and closes the reader itself.
+func (r *StreamableHTTPResponseReader) Close() error {
+	var err error
+	r.closeOne.Do(func() {
+		r.mu.Lock()
+		if r.notifyStopped != nil {
+			close(r.notifyStopped)
+		}
+		r.mu.Unlock()
+		err = r.writer.terminateSession(context.Background())
+		close(r.messages)
+	})
+	return trace.Wrap(err)
+}
+
+func (r *StreamableHTTPResponseReader) push(ctx context.Context, data string) error {
+	select {
+	case r.messages <- data:
+		return nil
+	case <-ctx.Done():
+		return trace.Wrap(ctx.Err())
+	}
+}
+
+// consumeEventStream reads every "message" event out of body, in order,
+// pushing each one to the reader's channel and tracking the last event ID
+// seen so a dropped notification stream can ask to resume from it.
+func (r *StreamableHTTPResponseReader) consumeEventStream(ctx context.Context, body io.ReadCloser) error {
+	br := bufio.NewReader(body)
+	for {
+		event, err := readSSEEvent(ctx, br)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		if event.ID != "" {
+			r.mu.Lock()
+			r.lastEventID = event.ID
+			r.mu.Unlock()
+		}
+		if event.EventType != SSEEventMessage {
+			continue
+		}
+		if err := r.push(ctx, event.Data); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+}
+
+// watchNotifications opens the optional long-lived GET stream servers may
+// offer for pushing notifications outside of a request/response exchange,
+// sending the last event ID seen so far, if any, as Last-Event-ID. It makes a
+// single attempt: it returns when the stream ends, ctx is done or the reader
+// is closed, and a stream that ends is not reopened. A stream error ends the
+// watch silently and never fails the reader, since the GET stream is a
+// best-effort addition on top of the required POST exchange.
+func (r *StreamableHTTPResponseReader) watchNotifications(ctx context.Context) {
+	r.mu.Lock()
+	stopped := make(chan struct{})
+	r.notifyStopped = stopped
+	r.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.baseURL.String(), nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if sessionID := r.writer.GetSessionID(); sessionID != "" {
+		req.Header.Set(mcpSessionIDHeader, sessionID)
+	}
+	if r.writer.oauth != nil {
+		if authHeader, err := r.writer.oauth.authorizationHeader(ctx); err == nil && authHeader != "" {
+			req.Header.Set("Authorization", authHeader)
+		}
+	}
+	r.mu.Lock()
+	lastEventID := r.lastEventID
+	r.mu.Unlock()
+	if lastEventID != "" {
+		req.Header.Set(lastEventIDHeader, lastEventID)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	// A server that doesn't support the optional GET stream is free to
+	// refuse it; there's nothing to watch in that case.
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.consumeEventStream(ctx, resp.Body)
+	}()
+
+	select {
+	case <-done:
+	case <-stopped:
+	case <-ctx.Done():
+	}
+}
+
+// ConnectStreamableHTTPServer connects to an MCP server speaking the
+// Streamable HTTP transport at baseURL and starts watching its optional
+// server-initiated notification stream. oauthCfg enables the MCP OAuth 2.1
+// authorization flow for servers that challenge requests with a
+// "WWW-Authenticate: Bearer" 401; pass nil to connect unauthenticated.
+func ConnectStreamableHTTPServer(ctx context.Context, baseURL *url.URL, oauthCfg *OAuthConfig) (*StreamableHTTPResponseReader, *StreamableHTTPRequestWriter, error) {
+	client, err := defaults.HTTPClient()
+	if err != nil {
+		return nil, nil, trace.Wrap(err, "making HTTP client")
+	}
+
+	var oauth *oauthAuthorizer
+	if oauthCfg != nil {
+		if err := oauthCfg.CheckAndSetDefaults(); err != nil {
+			return nil, nil, trace.Wrap(err)
+		}
+		oauth = newOAuthAuthorizer(client, baseURL, *oauthCfg)
+	}
+
+	reader := &StreamableHTTPResponseReader{
+		client:   client,
+		baseURL:  baseURL,
+		messages: make(chan string),
+	}
+	writer := &StreamableHTTPRequestWriter{
+		client:      client,
+		endpointURL: baseURL,
+		reader:      reader,
+		oauth:       oauth,
+	}
+	reader.writer = writer
+
+	go reader.watchNotifications(ctx)
+	return reader, writer, nil
+}
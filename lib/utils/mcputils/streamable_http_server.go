@@ -0,0 +1,264 @@
+/*
+ * Teleport
+ * Copyright (C) 2025  Gravitational, Inc.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mcputils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/gravitational/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// StreamableHTTPServerTransport implements TransportReader and
+// MessageWriter for the MCP Streamable HTTP transport, with Teleport
+// playing the server role: ServeHTTP is a single bidirectional endpoint
+// that accepts client messages over POST (answering a request with a single
+// JSON object; a POST response is never sent as an SSE stream) and,
+// optionally, a long-lived GET for server-initiated notifications. Every
+// response carries the transport's Mcp-Session-Id header. A request carrying
+// a different session ID is rejected with 404 Not Found; a request carrying
+// none is accepted.
+type StreamableHTTPServerTransport struct {
+	messages chan string
+	done     chan struct{}
+	closeOne sync.Once
+
+	mu        sync.Mutex
+	sessionID string
+	pending   map[string]chan mcp.JSONRPCMessage
+
+	notifyConn  http.ResponseWriter
+	notifyFlush func()
+}
+
+// NewStreamableHTTPServerTransport creates a StreamableHTTPServerTransport.
+func NewStreamableHTTPServerTransport() (*StreamableHTTPServerTransport, error) {
+	sessionID, err := newMCPSessionID()
+	if err != nil {
+		return nil, trace.Wrap(err, "generating session ID")
+	}
+	return &StreamableHTTPServerTransport{
+		messages:  make(chan string),
+		done:      make(chan struct{}),
+		pending:   make(map[string]chan mcp.JSONRPCMessage),
+		sessionID: sessionID,
+	}, nil
+}
+
+func newMCPSessionID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// GetSessionID returns the session ID this transport assigned to the
+// client on first contact.
+func (t *StreamableHTTPServerTransport) GetSessionID() string {
+	return t.sessionID
+}
+
+// Type returns "streamable_http".
+func (t *StreamableHTTPServerTransport) Type() string {
+	return "streamable_http"
+}
+
+// Close stops the transport. Any handler blocked in ServeHTTP returns
+// shortly after.
+func (t *StreamableHTTPServerTransport) Close() error {
+	t.closeOne.Do(func() { close(t.done) })
+	return nil
+}
+
+// ReadMessage returns the next message delivered to a POST request.
+func (t *StreamableHTTPServerTransport) ReadMessage(ctx context.Context) (string, error) {
+	select {
+	case msg, ok := <-t.messages:
+		if !ok {
+			return "", io.EOF
+		}
+		return msg, nil
+	case <-ctx.Done():
+		return "", trace.Wrap(ctx.Err())
+	case <-t.done:
+		return "", io.EOF
+	}
+}
+
+// WriteMessage delivers msg to whichever pending POST request it
+// correlates with by JSON-RPC ID. If msg doesn't correlate to a pending
+// request - a server-initiated notification, or a response whose request
+// has already timed out - it's instead streamed to the long-lived GET
+// notification connection, if one is connected.
+func (t *StreamableHTTPServerTransport) WriteMessage(ctx context.Context, msg mcp.JSONRPCMessage) error {
+	if id := responseID(msg); id != "" {
+		t.mu.Lock()
+		waitCh, ok := t.pending[id]
+		t.mu.Unlock()
+		if ok {
+			select {
+			case waitCh <- msg:
+				return nil
+			case <-ctx.Done():
+				return trace.Wrap(ctx.Err())
+			case <-t.done:
+				return trace.Errorf("transport closed")
+			}
+		}
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return trace.Wrap(err, "marshalling message")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.notifyConn == nil {
+		return trace.Errorf("no connected Streamable HTTP notification stream")
+	}
+	if _, err := fmt.Fprintf(t.notifyConn, "event: %s\ndata: %s\n\n", SSEEventMessage, data); err != nil {
+		return trace.Wrap(err, "writing Streamable HTTP notification")
+	}
+	t.notifyFlush()
+	return nil
+}
+
+// ServeHTTP implements http.Handler. POST delivers a client message,
+// blocking for the correlated response if the message was a request; GET
+// opens the optional long-lived notification stream.
+func (t *StreamableHTTPServerTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if sessionID := r.Header.Get(mcpSessionIDHeader); sessionID != "" && sessionID != t.sessionID {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	w.Header().Set(mcpSessionIDHeader, t.sessionID)
+
+	switch r.Method {
+	case http.MethodPost:
+		t.handlePost(w, r)
+	case http.MethodGet:
+		t.handleGet(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (t *StreamableHTTPServerTransport) handlePost(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var base baseJSONRPCMessage
+	var waitCh chan mcp.JSONRPCMessage
+	if json.Unmarshal(data, &base) == nil && base.isRequest() {
+		id := base.ID.String()
+		waitCh = make(chan mcp.JSONRPCMessage, 1)
+		t.mu.Lock()
+		t.pending[id] = waitCh
+		t.mu.Unlock()
+		defer func() {
+			t.mu.Lock()
+			delete(t.pending, id)
+			t.mu.Unlock()
+		}()
+	}
+
+	select {
+	case t.messages <- string(data):
+	case <-r.Context().Done():
+		return
+	case <-t.done:
+		http.Error(w, "transport closed", http.StatusGone)
+		return
+	}
+
+	if waitCh == nil {
+		// A notification or a response the client sent us; there's nothing
+		// to reply with.
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	select {
+	case resp := <-waitCh:
+		data, err := json.Marshal(resp)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	case <-r.Context().Done():
+	case <-t.done:
+		http.Error(w, "transport closed", http.StatusGone)
+	}
+}
+
+func (t *StreamableHTTPServerTransport) handleGet(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	t.mu.Lock()
+	t.notifyConn = w
+	t.notifyFlush = flusher.Flush
+	t.mu.Unlock()
+
+	select {
+	case <-r.Context().Done():
+	case <-t.done:
+	}
+}
+
+// responseID returns the JSON-RPC ID of msg if it's a response or error,
+// the only message kinds WriteMessage needs to correlate back to a pending
+// POST request, or "" for anything else (e.g. a server-initiated
+// notification).
+func responseID(msg mcp.JSONRPCMessage) string {
+	switch m := msg.(type) {
+	case mcp.JSONRPCResponse:
+		return m.ID.String()
+	case *mcp.JSONRPCResponse:
+		return m.ID.String()
+	case mcp.JSONRPCError:
+		return m.ID.String()
+	case *mcp.JSONRPCError:
+		return m.ID.String()
+	default:
+		return ""
+	}
+}
@@ -63,6 +63,48 @@ type ClientApplication interface {
 
 	TeleportClientTLSConfig(ctx context.Context, profileName, clusterName string) (*tls.Config, error)
 	SessionSSHCert(ctx context.Context, sshInfo *vnetv1.SshInfo, username string) ([]byte, crypto.Signer, error)
+
+	// AgentForwardingPermitted reports whether the user's SSH agent may be
+	// forwarded through VNet to the SSH node identified by sshInfo. Client
+	// applications should consult their own per-app policy or configuration
+	// (e.g. an "Allow agent forwarding" setting) before permitting this, since
+	// it grants the target node the ability to make signing requests against
+	// the user's agent for the lifetime of the session.
+	AgentForwardingPermitted(ctx context.Context, sshInfo *vnetv1.SshInfo) bool
+
+	// OnSFTPRequest gets called for every file operation (open, read, write,
+	// rename, remove) VNet observes on an SFTP session proxied through an SSH
+	// node, before it's forwarded to the node.
+	// Returning an error denies the operation; the client is sent an
+	// SSH_FX_PERMISSION_DENIED status including the error text instead of the
+	// request being forwarded.
+	OnSFTPRequest(ctx context.Context, sshInfo *vnetv1.SshInfo, req *SFTPFileRequest) error
+
+	// SSHPasswordFallbackConfig reports whether VNet's SSH listener should
+	// accept password and keyboard-interactive auth as a fallback for
+	// clients that never successfully complete publickey auth (some older
+	// PuTTY builds and libssh-based tools get confused by VNet's publickey
+	// probing and never connect at all). When enabled, usernameSuffix is the
+	// suffix (e.g. "+password") a client appends to its real username to opt
+	// an individual connection into the fallback; VNet strips it before
+	// dialing the target. Real authentication still happens downstream via
+	// the user's Teleport-issued SSH cert, so any password or challenge
+	// response is accepted once a client asks for the fallback this way.
+	SSHPasswordFallbackConfig(ctx context.Context) (enabled bool, usernameSuffix string)
+
+	// SSHSessionRecordingConfig reports whether the SSH session identified
+	// by sshInfo should be recorded, mirroring the cluster's "record at
+	// node" session-recording mode - something the Teleport proxy cannot
+	// apply to VNet's peer-to-peer SSH flows, since it never terminates
+	// them itself.
+	SSHSessionRecordingConfig(ctx context.Context, sshInfo *vnetv1.SshInfo) bool
+
+	// RecordSSHSessionEvent emits a single event - a session start/end, a
+	// pty-req/window-change/exec/shell/subsystem request, or a chunk of raw
+	// terminal I/O - into the session-recording backend for the SSH session
+	// identified by sshInfo. It's only called when
+	// SSHSessionRecordingConfig has enabled recording for that session.
+	RecordSSHSessionEvent(ctx context.Context, sshInfo *vnetv1.SshInfo, event *SSHSessionRecordingEvent) error
 }
 
 // ClusterClient is an interface defining the subset of [client.ClusterClient]
@@ -19,6 +19,9 @@ package vnet
 import (
 	"context"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
 	"crypto/rsa"
 	"io"
 
@@ -39,19 +42,38 @@ func (s *remoteSigner) Public() crypto.PublicKey {
 }
 
 // Sign implements [crypto.Signer.Sign] and issues a signature over digest for
-// the associated app.
+// the associated app. The remote side is told which key algorithm to use via
+// [vnetv1.SignRequest.KeyAlgorithm], since the hash alone doesn't disambiguate
+// an RSA digest from an ECDSA one, and Ed25519 signs the full message rather
+// than a digest at all.
 func (s *remoteSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	keyAlgorithm, err := keyAlgorithmFor(s.pub)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	req := &vnetv1.SignRequest{
-		Digest: digest,
+		Digest:       digest,
+		KeyAlgorithm: keyAlgorithm,
 	}
 	switch opts.HashFunc() {
 	case 0:
+		if keyAlgorithm != vnetv1.KeyAlgorithm_KEY_ALGORITHM_ED25519 {
+			return nil, trace.BadParameter("hash func is required for all signature algorithms except Ed25519")
+		}
 		req.Hash = vnetv1.Hash_HASH_NONE
 	case crypto.SHA256:
 		req.Hash = vnetv1.Hash_HASH_SHA256
+	case crypto.SHA384:
+		req.Hash = vnetv1.Hash_HASH_SHA384
+	case crypto.SHA512:
+		req.Hash = vnetv1.Hash_HASH_SHA512
 	default:
 		return nil, trace.BadParameter("unsupported signature hash func %v", opts.HashFunc())
 	}
+	if opts.HashFunc() != 0 && len(digest) != opts.HashFunc().Size() {
+		return nil, trace.BadParameter("digest length %d does not match hash func size %d", len(digest), opts.HashFunc().Size())
+	}
 	if pssOpts, ok := opts.(*rsa.PSSOptions); ok {
 		saltLen := int32(pssOpts.SaltLength)
 		req.PssSaltLength = &saltLen
@@ -62,3 +84,82 @@ func (s *remoteSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpt
 	}
 	return signature, nil
 }
+
+// dispatchRemoteSign is the remote side of [remoteSigner.Sign]: it runs in
+// the client application process, which is the only place the real private
+// key ever exists, and does the actual crypto/ecdsa, crypto/ed25519, or
+// crypto/rsa work for a [vnetv1.SignRequest] received over SignForApp.
+func dispatchRemoteSign(key crypto.Signer, req *vnetv1.SignRequest) ([]byte, error) {
+	switch req.GetKeyAlgorithm() {
+	case vnetv1.KeyAlgorithm_KEY_ALGORITHM_ED25519:
+		edKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, trace.BadParameter("key algorithm is Ed25519 but key is %T", key)
+		}
+		if req.GetHash() != vnetv1.Hash_HASH_NONE {
+			return nil, trace.BadParameter("Ed25519 signs the full message, hash must be HASH_NONE")
+		}
+		return ed25519.Sign(edKey, req.GetDigest()), nil
+
+	case vnetv1.KeyAlgorithm_KEY_ALGORITHM_ECDSA:
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, trace.BadParameter("key algorithm is ECDSA but key is %T", key)
+		}
+		if _, err := hashFuncForRemoteSign(req.GetHash()); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		sig, err := ecdsa.SignASN1(rand.Reader, ecKey, req.GetDigest())
+		return sig, trace.Wrap(err)
+
+	case vnetv1.KeyAlgorithm_KEY_ALGORITHM_RSA:
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, trace.BadParameter("key algorithm is RSA but key is %T", key)
+		}
+		hash, err := hashFuncForRemoteSign(req.GetHash())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if saltLen := req.GetPssSaltLength(); saltLen != 0 {
+			sig, err := rsaKey.Sign(rand.Reader, req.GetDigest(), &rsa.PSSOptions{SaltLength: int(saltLen), Hash: hash})
+			return sig, trace.Wrap(err)
+		}
+		sig, err := rsaKey.Sign(rand.Reader, req.GetDigest(), hash)
+		return sig, trace.Wrap(err)
+
+	default:
+		return nil, trace.BadParameter("unsupported key algorithm %v", req.GetKeyAlgorithm())
+	}
+}
+
+// hashFuncForRemoteSign maps a [vnetv1.Hash] to the [crypto.Hash] it
+// identifies, for the RSA and ECDSA paths of [dispatchRemoteSign].
+func hashFuncForRemoteSign(h vnetv1.Hash) (crypto.Hash, error) {
+	switch h {
+	case vnetv1.Hash_HASH_SHA256:
+		return crypto.SHA256, nil
+	case vnetv1.Hash_HASH_SHA384:
+		return crypto.SHA384, nil
+	case vnetv1.Hash_HASH_SHA512:
+		return crypto.SHA512, nil
+	default:
+		return 0, trace.BadParameter("unsupported signature hash %v", h)
+	}
+}
+
+// keyAlgorithmFor returns the [vnetv1.KeyAlgorithm] identifying pub, so the
+// remote side knows whether to return an ASN.1 ECDSA signature, a raw
+// Ed25519 signature, or a PKCS#1 v1.5/PSS RSA signature.
+func keyAlgorithmFor(pub crypto.PublicKey) (vnetv1.KeyAlgorithm, error) {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return vnetv1.KeyAlgorithm_KEY_ALGORITHM_RSA, nil
+	case *ecdsa.PublicKey:
+		return vnetv1.KeyAlgorithm_KEY_ALGORITHM_ECDSA, nil
+	case ed25519.PublicKey:
+		return vnetv1.KeyAlgorithm_KEY_ALGORITHM_ED25519, nil
+	default:
+		return 0, trace.BadParameter("unsupported public key type %T", pub)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"time"
 
 	"github.com/gravitational/trace"
 	"golang.org/x/crypto/ssh"
@@ -70,18 +71,18 @@ func (p *remoteSSHProvider) TeleportClientTLSConfig(ctx context.Context, profile
 	}, nil
 }
 
-func (p *remoteSSHProvider) UserSSHConfig(ctx context.Context, sshInfo *vnetv1.SshInfo, username string) (*ssh.ClientConfig, error) {
+func (p *remoteSSHProvider) UserSSHConfig(ctx context.Context, sshInfo *vnetv1.SshInfo, username string) (*ssh.ClientConfig, time.Time, error) {
 	cert, err := p.clt.ReissueSSHCert(ctx, sshInfo, username)
 	if err != nil {
-		return nil, trace.Wrap(err)
+		return nil, time.Time{}, trace.Wrap(err)
 	}
 	sshPub, _, _, _, err := ssh.ParseAuthorizedKey(cert)
 	if err != nil {
-		return nil, trace.Wrap(err, "parsing SSH certificate")
+		return nil, time.Time{}, trace.Wrap(err, "parsing SSH certificate")
 	}
 	sshCert, ok := sshPub.(*ssh.Certificate)
 	if !ok {
-		return nil, trace.BadParameter("expected ssh.Certificate, got %T", sshCert)
+		return nil, time.Time{}, trace.BadParameter("expected ssh.Certificate, got %T", sshCert)
 	}
 	signer := &remoteSigner{
 		pub: sshCert.Key.(ssh.CryptoPublicKey).CryptoPublicKey(),
@@ -95,15 +96,56 @@ func (p *remoteSSHProvider) UserSSHConfig(ctx context.Context, sshInfo *vnetv1.S
 	}
 	sshSigner, err := ssh.NewSignerFromSigner(signer)
 	if err != nil {
-		return nil, trace.Wrap(err)
+		return nil, time.Time{}, trace.Wrap(err)
 	}
 	sshSigner, err = ssh.NewCertSigner(sshCert, sshSigner)
 	if err != nil {
-		return nil, trace.Wrap(err)
+		return nil, time.Time{}, trace.Wrap(err)
 	}
+
+	var expiry time.Time
+	if sshCert.ValidBefore != ssh.CertTimeInfinity {
+		expiry = time.Unix(int64(sshCert.ValidBefore), 0)
+	}
+
 	return &ssh.ClientConfig{
 		Auth:            []ssh.AuthMethod{ssh.PublicKeys(sshSigner)},
 		User:            username,
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-	}, nil
+	}, expiry, nil
+}
+
+func (p *remoteSSHProvider) AgentForwardingPermitted(ctx context.Context, sshInfo *vnetv1.SshInfo) bool {
+	allowed, err := p.clt.AgentForwardingPermitted(ctx, sshInfo)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to check SSH agent forwarding policy, refusing to forward agent", "error", err)
+		return false
+	}
+	return allowed
+}
+
+func (p *remoteSSHProvider) OnSFTPRequest(ctx context.Context, sshInfo *vnetv1.SshInfo, req *SFTPFileRequest) error {
+	return trace.Wrap(p.clt.OnSFTPRequest(ctx, sshInfo, req))
+}
+
+func (p *remoteSSHProvider) SSHPasswordFallbackConfig(ctx context.Context) (bool, string) {
+	enabled, usernameSuffix, err := p.clt.SSHPasswordFallbackConfig(ctx)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to get SSH password auth fallback config, leaving it disabled", "error", err)
+		return false, ""
+	}
+	return enabled, usernameSuffix
+}
+
+func (p *remoteSSHProvider) SSHSessionRecordingConfig(ctx context.Context, sshInfo *vnetv1.SshInfo) bool {
+	enabled, err := p.clt.SSHSessionRecordingConfig(ctx, sshInfo)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to get SSH session recording config, leaving it disabled", "error", err)
+		return false
+	}
+	return enabled
+}
+
+func (p *remoteSSHProvider) RecordSSHSessionEvent(ctx context.Context, sshInfo *vnetv1.SshInfo, event *SSHSessionRecordingEvent) error {
+	return trace.Wrap(p.clt.RecordSSHSessionEvent(ctx, sshInfo, event))
 }
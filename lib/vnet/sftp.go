@@ -0,0 +1,426 @@
+// Teleport
+// Copyright (C) 2025 Gravitational, Inc.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package vnet
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/gravitational/trace"
+	"golang.org/x/crypto/ssh"
+)
+
+// sftpSubsystemName is the SSH subsystem name clients request on a session
+// channel in order to start an SFTP session, per RFC 4254 section 6.5.
+const sftpSubsystemName = "sftp"
+
+// SFTP packet types, as defined in section 3 of
+// draft-ietf-secsh-filexfer-02, the SFTP version (3) implemented by OpenSSH
+// and therefore by every client VNet needs to proxy. Only the types this
+// handler needs to recognize in order to audit and authorize file
+// operations are listed here; every other packet type is proxied through
+// unmodified.
+const (
+	sftpPacketTypeOpen   = 3
+	sftpPacketTypeClose  = 4
+	sftpPacketTypeRead   = 5
+	sftpPacketTypeWrite  = 6
+	sftpPacketTypeRemove = 13
+	sftpPacketTypeRename = 18
+	sftpPacketTypeStatus = 101
+	sftpPacketTypeHandle = 102
+)
+
+// sftpStatusPermissionDenied is the SSH_FX_PERMISSION_DENIED status code
+// VNet returns to the client in place of forwarding a file operation that
+// ClientApplication.OnSFTPRequest denied.
+const sftpStatusPermissionDenied = 3
+
+// SFTPOperation identifies the kind of file operation described by an
+// SFTPFileRequest.
+type SFTPOperation string
+
+const (
+	SFTPOperationOpen   SFTPOperation = "open"
+	SFTPOperationRead   SFTPOperation = "read"
+	SFTPOperationWrite  SFTPOperation = "write"
+	SFTPOperationRename SFTPOperation = "rename"
+	SFTPOperationRemove SFTPOperation = "remove"
+)
+
+// SFTPFileRequest describes a single file operation observed on an SFTP
+// session proxied through VNet, passed to ClientApplication.OnSFTPRequest
+// for audit logging and per-operation authorization.
+type SFTPFileRequest struct {
+	// Operation is the kind of file operation being requested.
+	Operation SFTPOperation
+	// Path is the file or directory the operation targets. For
+	// SFTPOperationRename this is the source path. For SFTPOperationRead and
+	// SFTPOperationWrite the path is only known if VNet observed the
+	// preceding SFTPOperationOpen that produced the handle being used, and
+	// is left empty otherwise.
+	Path string
+	// NewPath is the rename destination. It's only set for
+	// SFTPOperationRename.
+	NewPath string
+}
+
+// interceptSFTPSubsystem wraps every incoming "session" channel so that, if
+// the client goes on to request the "sftp" subsystem on it, sshHandler takes
+// over the channel itself and proxies it as a parsed SFTP stream instead of
+// handing it to the generic session proxy. Channels on which the sftp
+// subsystem is never requested (shells, execs, port forwards, ...) are
+// passed through completely unmodified.
+func (h *sshHandler) interceptSFTPSubsystem(ctx context.Context, targetClient *ssh.Client, chans <-chan ssh.NewChannel) <-chan ssh.NewChannel {
+	out := make(chan ssh.NewChannel)
+	go func() {
+		defer close(out)
+		for newChannel := range chans {
+			if newChannel.ChannelType() != "session" {
+				out <- newChannel
+				continue
+			}
+			out <- &sftpSniffingChannel{
+				NewChannel:   newChannel,
+				h:            h,
+				ctx:          ctx,
+				targetClient: targetClient,
+			}
+		}
+	}()
+	return out
+}
+
+// sftpSniffingChannel wraps an ssh.NewChannel of type "session" so that,
+// once the caller accepts it, the resulting request stream is watched for a
+// "subsystem" request naming sftp.
+type sftpSniffingChannel struct {
+	ssh.NewChannel
+	h            *sshHandler
+	ctx          context.Context
+	targetClient *ssh.Client
+}
+
+func (c *sftpSniffingChannel) Accept() (ssh.Channel, <-chan *ssh.Request, error) {
+	channel, requests, err := c.NewChannel.Accept()
+	if err != nil {
+		return nil, nil, err
+	}
+	out := make(chan *ssh.Request)
+	go c.h.watchForSFTPSubsystem(c.ctx, c.targetClient, channel, requests, out)
+	return channel, out, nil
+}
+
+// subsystemRequestPayload mirrors the payload of a "subsystem" channel
+// request, as defined in RFC 4254 section 6.5.
+type subsystemRequestPayload struct {
+	Subsystem string
+}
+
+// watchForSFTPSubsystem relays requests from in to out unmodified until it
+// sees a request for the sftp subsystem, at which point it takes over the
+// channel to proxy it as SFTP and stops relaying further requests.
+func (h *sshHandler) watchForSFTPSubsystem(ctx context.Context, targetClient *ssh.Client, localChan ssh.Channel, in <-chan *ssh.Request, out chan<- *ssh.Request) {
+	defer close(out)
+	for req := range in {
+		if req.Type != "subsystem" {
+			out <- req
+			continue
+		}
+		var payload subsystemRequestPayload
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil || payload.Subsystem != sftpSubsystemName {
+			out <- req
+			continue
+		}
+		h.takeOverSFTPChannel(ctx, targetClient, localChan, req, in)
+		return
+	}
+}
+
+// takeOverSFTPChannel opens a matching sftp session on targetClient, replies
+// to the client's subsystem request, and proxies the two channels as a
+// parsed SFTP stream for the rest of the channel's lifetime.
+func (h *sshHandler) takeOverSFTPChannel(ctx context.Context, targetClient *ssh.Client, localChan ssh.Channel, subsystemReq *ssh.Request, remainingRequests <-chan *ssh.Request) {
+	targetChan, targetRequests, err := targetClient.OpenChannel("session", nil)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to open session for SFTP on target node", "error", err)
+		if subsystemReq.WantReply {
+			subsystemReq.Reply(false, nil)
+		}
+		return
+	}
+	go ssh.DiscardRequests(targetRequests)
+
+	ok, err := targetChan.SendRequest("subsystem", true, ssh.Marshal(subsystemRequestPayload{Subsystem: sftpSubsystemName}))
+	if err != nil || !ok {
+		log.WarnContext(ctx, "Target node refused SFTP subsystem request", "error", err)
+		targetChan.Close()
+		if subsystemReq.WantReply {
+			subsystemReq.Reply(false, nil)
+		}
+		return
+	}
+	if subsystemReq.WantReply {
+		if err := subsystemReq.Reply(true, nil); err != nil {
+			log.DebugContext(ctx, "Failed to reply to SFTP subsystem request", "error", err)
+		}
+	}
+
+	go ssh.DiscardRequests(remainingRequests)
+	h.proxySFTPSubsystem(ctx, localChan, targetChan)
+}
+
+// proxySFTPSubsystem copies SFTP protocol packets bidirectionally between
+// localChan and targetChan, parsing each one to emit an audit log entry and
+// consult ClientApplication.OnSFTPRequest before forwarding file operations.
+// Operations OnSFTPRequest denies are answered with an SSH_FX_PERMISSION_DENIED
+// status instead of being forwarded to the target.
+func (h *sshHandler) proxySFTPSubsystem(ctx context.Context, localChan, targetChan ssh.Channel) {
+	defer localChan.Close()
+	defer targetChan.Close()
+
+	var (
+		mu          sync.Mutex
+		pendingOpen = make(map[uint32]string) // request ID -> path, for OPEN requests awaiting their HANDLE response
+		handlePath  = make(map[string]string) // sftp handle -> path, once known
+	)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		h.proxySFTPRequests(ctx, localChan, targetChan, &mu, pendingOpen, handlePath)
+	}()
+	go func() {
+		defer wg.Done()
+		h.proxySFTPResponses(ctx, localChan, targetChan, &mu, pendingOpen, handlePath)
+	}()
+	wg.Wait()
+}
+
+// proxySFTPRequests reads SFTP packets sent by the client, auditing and
+// authorizing the file operations among them before forwarding the packet
+// to targetChan. A denied operation is answered with an
+// SSH_FX_PERMISSION_DENIED status instead of being forwarded.
+func (h *sshHandler) proxySFTPRequests(ctx context.Context, localChan, targetChan ssh.Channel, mu *sync.Mutex, pendingOpen map[uint32]string, handlePath map[string]string) {
+	for {
+		pkt, err := readSFTPPacket(localChan)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.DebugContext(ctx, "Error reading SFTP request from client", "error", err)
+			}
+			return
+		}
+
+		id, req := parseSFTPFileRequest(pkt)
+		if req != nil {
+			if req.Operation == SFTPOperationRead || req.Operation == SFTPOperationWrite {
+				mu.Lock()
+				req.Path = handlePath[req.Path]
+				mu.Unlock()
+			}
+
+			if err := h.sshProvider.OnSFTPRequest(ctx, h.sshInfo, req); err != nil {
+				log.InfoContext(ctx, "Denying SFTP file operation", "operation", req.Operation, "path", req.Path, "error", err)
+				if err := writeSFTPStatus(localChan, id, sftpStatusPermissionDenied, err.Error()); err != nil {
+					log.DebugContext(ctx, "Failed to write SFTP denial status", "error", err)
+					return
+				}
+				continue
+			}
+			log.InfoContext(ctx, "Proxying SFTP file operation", "operation", req.Operation, "path", req.Path)
+
+			if req.Operation == SFTPOperationOpen {
+				mu.Lock()
+				pendingOpen[id] = req.Path
+				mu.Unlock()
+			}
+		}
+
+		if _, err := targetChan.Write(pkt.bytes()); err != nil {
+			log.DebugContext(ctx, "Error forwarding SFTP request to target", "error", err)
+			return
+		}
+	}
+}
+
+// proxySFTPResponses copies SFTP packets from targetChan back to localChan,
+// tracking which sftp handle each SSH_FXP_OPEN call produced so that later
+// reads and writes against that handle can be audited with the path they
+// actually affect.
+func (h *sshHandler) proxySFTPResponses(ctx context.Context, localChan, targetChan ssh.Channel, mu *sync.Mutex, pendingOpen map[uint32]string, handlePath map[string]string) {
+	for {
+		pkt, err := readSFTPPacket(targetChan)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.DebugContext(ctx, "Error reading SFTP response from target", "error", err)
+			}
+			return
+		}
+
+		if pkt.typ == sftpPacketTypeHandle {
+			if id, rest, err := sftpRequestID(pkt.payload); err == nil {
+				if handle, _, err := readSFTPString(rest); err == nil {
+					mu.Lock()
+					if path, ok := pendingOpen[id]; ok {
+						handlePath[handle] = path
+						delete(pendingOpen, id)
+					}
+					mu.Unlock()
+				}
+			}
+		}
+
+		if _, err := localChan.Write(pkt.bytes()); err != nil {
+			log.DebugContext(ctx, "Error forwarding SFTP response to client", "error", err)
+			return
+		}
+	}
+}
+
+// sftpPacket is a single SFTP protocol message: a 4 byte length (of
+// everything that follows), a 1 byte type, and a type-dependent payload, as
+// defined in section 3 of draft-ietf-secsh-filexfer-02.
+type sftpPacket struct {
+	typ     byte
+	payload []byte
+}
+
+func readSFTPPacket(r io.Reader) (*sftpPacket, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length == 0 {
+		return nil, trace.BadParameter("invalid SFTP packet: zero length")
+	}
+	payload := make([]byte, length-1)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return &sftpPacket{typ: header[4], payload: payload}, nil
+}
+
+// bytes re-serializes the packet to the wire format it was read in, so it
+// can be forwarded to the other side unmodified.
+func (p *sftpPacket) bytes() []byte {
+	out := make([]byte, 5+len(p.payload))
+	binary.BigEndian.PutUint32(out[:4], uint32(len(p.payload)+1))
+	out[4] = p.typ
+	copy(out[5:], p.payload)
+	return out
+}
+
+// sftpRequestID reads the 4 byte request ID that prefixes every SFTP
+// request and response packet other than SSH_FXP_INIT and SSH_FXP_VERSION,
+// returning the remaining, unread payload.
+func sftpRequestID(payload []byte) (id uint32, rest []byte, err error) {
+	if len(payload) < 4 {
+		return 0, nil, trace.BadParameter("truncated SFTP packet")
+	}
+	return binary.BigEndian.Uint32(payload[:4]), payload[4:], nil
+}
+
+// readSFTPString reads a single SFTP protocol string: a 4 byte length
+// followed by that many bytes of (not NUL-terminated) data.
+func readSFTPString(b []byte) (s string, rest []byte, err error) {
+	if len(b) < 4 {
+		return "", nil, trace.BadParameter("truncated SFTP string")
+	}
+	n := binary.BigEndian.Uint32(b[:4])
+	if uint32(len(b)-4) < n {
+		return "", nil, trace.BadParameter("truncated SFTP string")
+	}
+	return string(b[4 : 4+n]), b[4+n:], nil
+}
+
+// parseSFTPFileRequest extracts an SFTPFileRequest and its request ID from
+// pkt, returning a nil request for packet types that don't represent a file
+// operation worth auditing (SSH_FXP_INIT, SSH_FXP_STAT, directory listings,
+// and the like are proxied through without inspection). Parse failures are
+// treated the same as an uninteresting packet type: the packet is still
+// forwarded, just without an audit entry, so a client sending a malformed
+// packet can't wedge the proxy.
+func parseSFTPFileRequest(pkt *sftpPacket) (id uint32, req *SFTPFileRequest) {
+	id, rest, err := sftpRequestID(pkt.payload)
+	if err != nil {
+		return 0, nil
+	}
+
+	switch pkt.typ {
+	case sftpPacketTypeOpen:
+		path, _, err := readSFTPString(rest)
+		if err != nil {
+			return 0, nil
+		}
+		return id, &SFTPFileRequest{Operation: SFTPOperationOpen, Path: path}
+
+	case sftpPacketTypeRemove:
+		path, _, err := readSFTPString(rest)
+		if err != nil {
+			return 0, nil
+		}
+		return id, &SFTPFileRequest{Operation: SFTPOperationRemove, Path: path}
+
+	case sftpPacketTypeRename:
+		oldPath, rest, err := readSFTPString(rest)
+		if err != nil {
+			return 0, nil
+		}
+		newPath, _, err := readSFTPString(rest)
+		if err != nil {
+			return 0, nil
+		}
+		return id, &SFTPFileRequest{Operation: SFTPOperationRename, Path: oldPath, NewPath: newPath}
+
+	case sftpPacketTypeRead, sftpPacketTypeWrite:
+		// The handle, not the path, is on the wire here. Path is populated
+		// with the raw handle so the caller can resolve it against
+		// handlePath; parseSFTPFileRequest has no access to that state.
+		handle, _, err := readSFTPString(rest)
+		if err != nil {
+			return 0, nil
+		}
+		op := SFTPOperationRead
+		if pkt.typ == sftpPacketTypeWrite {
+			op = SFTPOperationWrite
+		}
+		return id, &SFTPFileRequest{Operation: op, Path: handle}
+
+	default:
+		return 0, nil
+	}
+}
+
+// writeSFTPStatus writes an SSH_FXP_STATUS packet to w in response to
+// request id, per section 7 of draft-ietf-secsh-filexfer-02.
+func writeSFTPStatus(w io.Writer, id uint32, code uint32, message string) error {
+	payload := make([]byte, 0, 4+4+4+len(message)+4)
+	payload = binary.BigEndian.AppendUint32(payload, id)
+	payload = binary.BigEndian.AppendUint32(payload, code)
+	payload = binary.BigEndian.AppendUint32(payload, uint32(len(message)))
+	payload = append(payload, message...)
+	payload = binary.BigEndian.AppendUint32(payload, 0) // language tag, left empty
+
+	pkt := &sftpPacket{typ: sftpPacketTypeStatus, payload: payload}
+	_, err := w.Write(pkt.bytes())
+	return err
+}
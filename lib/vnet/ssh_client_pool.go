@@ -0,0 +1,285 @@
+// Teleport
+// Copyright (C) 2025 Gravitational, Inc.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package vnet
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// sshClientPoolIdleTimeout is how long a pooled SSH client may go
+	// without being handed out before the background health checker closes
+	// and evicts it.
+	sshClientPoolIdleTimeout = 5 * time.Minute
+
+	// sshClientPoolHealthCheckInterval is how often pooled clients are
+	// health-checked and idle-reaped in the background.
+	sshClientPoolHealthCheckInterval = 30 * time.Second
+
+	// sshClientPoolKeepAliveTimeout bounds each individual health check.
+	sshClientPoolKeepAliveTimeout = 5 * time.Second
+
+	// sshClientPoolMaxPerKey caps the number of live upstream SSH clients
+	// kept open for a single (username, cluster, hostname). Once at the cap,
+	// new connections reuse the least-recently-used member instead of
+	// dialing another.
+	sshClientPoolMaxPerKey = 4
+
+	// sshKeepAliveRequestType is the request type OpenSSH and most other SSH
+	// servers reply to as a liveness check. It carries no meaning beyond
+	// "are you still there".
+	sshKeepAliveRequestType = "keepalive@openssh.com"
+)
+
+// sshClientPoolKey identifies a set of interchangeable upstream SSH clients:
+// the same user connecting to the same host in the same cluster.
+type sshClientPoolKey struct {
+	username string
+	cluster  string
+	hostname string
+}
+
+// sshClientDialFunc dials a fresh upstream *ssh.Client for a pool key,
+// along with the expiry of the cert it authenticated with (the zero Time
+// if the cert never expires).
+type sshClientDialFunc func(ctx context.Context) (*ssh.Client, time.Time, error)
+
+// pooledSSHClient is a single upstream SSH connection kept alive for reuse
+// across many incoming VNet SSH connections that resolve to the same pool
+// key. Because sshClientPoolMaxPerKey lets several unrelated incoming
+// connections share one entry's client concurrently, refs tracks how many
+// are currently holding it: once evicted, an entry's client is only closed
+// once every holder has released it, so evicting it for one connection's
+// benefit can never sever another connection still using it.
+type pooledSSHClient struct {
+	client     *ssh.Client
+	lastUsed   time.Time
+	certExpiry time.Time
+	refs       int
+	evicted    bool
+}
+
+// sshClientPool keeps a bounded set of live upstream *ssh.Client per
+// sshClientPoolKey, so interactive tools that open many short-lived SSH
+// sessions to the same node (rsync, scp, VSCode Remote-SSH, ansible) reuse
+// an established connection instead of paying for a fresh TCP dial and SSH
+// handshake on every one.
+type sshClientPool struct {
+	clock clockwork.Clock
+
+	mu      sync.Mutex
+	entries map[sshClientPoolKey][]*pooledSSHClient
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newSSHClientPool starts a client pool and its background health checker.
+// Callers must call Close to stop the health checker and close every pooled
+// client.
+func newSSHClientPool(clock clockwork.Clock) *sshClientPool {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &sshClientPool{
+		clock:   clock,
+		entries: make(map[sshClientPoolKey][]*pooledSSHClient),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go p.healthCheckLoop(ctx)
+	return p
+}
+
+// get returns a pooled client for key, dialing a fresh one with dial if the
+// pool has fewer than sshClientPoolMaxPerKey live members for key. Once at
+// the cap, the least-recently-used member is returned instead of opening
+// another upstream connection, so it may be handed out to several callers
+// at once. The returned release func must be called exactly once, with
+// whether the client is still healthy, when the caller is done with it.
+func (p *sshClientPool) get(ctx context.Context, key sshClientPoolKey, dial sshClientDialFunc) (*ssh.Client, func(healthy bool), error) {
+	p.mu.Lock()
+	if entries := p.entries[key]; len(entries) >= sshClientPoolMaxPerKey {
+		lru := entries[0]
+		for _, e := range entries[1:] {
+			if e.lastUsed.Before(lru.lastUsed) {
+				lru = e
+			}
+		}
+		lru.lastUsed = p.clock.Now()
+		lru.refs++
+		client := lru.client
+		p.mu.Unlock()
+		return client, p.releaseFunc(key, lru), nil
+	}
+	p.mu.Unlock()
+
+	client, certExpiry, err := dial(ctx)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+
+	e := &pooledSSHClient{client: client, lastUsed: p.clock.Now(), certExpiry: certExpiry, refs: 1}
+	p.mu.Lock()
+	p.entries[key] = append(p.entries[key], e)
+	p.mu.Unlock()
+	return client, p.releaseFunc(key, e), nil
+}
+
+// releaseFunc returns the release func handed back to a get caller for
+// entry e under key. Calling it with healthy=false evicts e so no future
+// get() call returns it again, but e.client is only closed once every
+// current holder - including this one - has released it.
+func (p *sshClientPool) releaseFunc(key sshClientPoolKey, e *pooledSSHClient) func(healthy bool) {
+	return func(healthy bool) {
+		p.mu.Lock()
+		e.refs--
+		if !healthy {
+			p.removeFromMapLocked(key, e)
+		}
+		shouldClose := e.evicted && e.refs <= 0
+		p.mu.Unlock()
+		if shouldClose {
+			e.client.Close()
+		}
+	}
+}
+
+// removeFromMapLocked removes e from the pool's map under key so it's never
+// handed out by get() again, and marks it evicted so its last remaining
+// holder closes it on release instead of another goroutine closing it out
+// from under a holder still using it. Callers must hold p.mu.
+func (p *sshClientPool) removeFromMapLocked(key sshClientPoolKey, e *pooledSSHClient) {
+	entries := p.entries[key]
+	for i, cand := range entries {
+		if cand == e {
+			p.entries[key] = append(entries[:i:i], entries[i+1:]...)
+			break
+		}
+	}
+	e.evicted = true
+}
+
+// Close stops the background health checker and closes every pooled client,
+// regardless of whether any caller has released it yet.
+func (p *sshClientPool) Close() error {
+	p.cancel()
+	<-p.done
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, entries := range p.entries {
+		for _, e := range entries {
+			e.client.Close()
+		}
+	}
+	p.entries = nil
+	return nil
+}
+
+func (p *sshClientPool) healthCheckLoop(ctx context.Context) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(sshClientPoolHealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.reapUnhealthyAndIdle()
+		}
+	}
+}
+
+// reapUnhealthyAndIdle evicts every pooled client that's been idle for
+// longer than sshClientPoolIdleTimeout or whose cert has expired, plus any
+// that fail a keepalive health check. Eviction here never closes a client
+// still in use by another connection sharing it; closing is deferred to
+// that connection's own release call.
+func (p *sshClientPool) reapUnhealthyAndIdle() {
+	type candidate struct {
+		key   sshClientPoolKey
+		entry *pooledSSHClient
+	}
+
+	p.mu.Lock()
+	now := p.clock.Now()
+	var toEvict []candidate
+	var toCheck []candidate
+	for key, entries := range p.entries {
+		for _, e := range entries {
+			switch {
+			case now.Sub(e.lastUsed) > sshClientPoolIdleTimeout:
+				toEvict = append(toEvict, candidate{key: key, entry: e})
+			case !e.certExpiry.IsZero() && !now.Before(e.certExpiry):
+				toEvict = append(toEvict, candidate{key: key, entry: e})
+			default:
+				toCheck = append(toCheck, candidate{key: key, entry: e})
+			}
+		}
+	}
+	for _, c := range toEvict {
+		p.removeFromMapLocked(c.key, c.entry)
+	}
+	p.mu.Unlock()
+
+	for _, c := range toEvict {
+		p.closeIfUnused(c.entry)
+	}
+	for _, c := range toCheck {
+		if !sendSSHKeepAlive(c.entry.client) {
+			p.mu.Lock()
+			p.removeFromMapLocked(c.key, c.entry)
+			p.mu.Unlock()
+			p.closeIfUnused(c.entry)
+		}
+	}
+}
+
+// closeIfUnused closes e.client if no caller currently holds a reference to
+// it, i.e. every holder has already called its release func.
+func (p *sshClientPool) closeIfUnused(e *pooledSSHClient) {
+	p.mu.Lock()
+	shouldClose := e.refs <= 0
+	p.mu.Unlock()
+	if shouldClose {
+		e.client.Close()
+	}
+}
+
+// sendSSHKeepAlive sends an OpenSSH keepalive request and reports whether
+// client replied in time, the standard way to check the liveness of an SSH
+// connection that isn't actively being used for anything else.
+func sendSSHKeepAlive(client *ssh.Client) bool {
+	result := make(chan bool, 1)
+	go func() {
+		_, _, err := client.SendRequest(sshKeepAliveRequestType, true, nil)
+		result <- err == nil
+	}()
+	select {
+	case ok := <-result:
+		return ok
+	case <-time.After(sshClientPoolKeepAliveTimeout):
+		return false
+	}
+}
@@ -19,8 +19,10 @@ package vnet
 import (
 	"context"
 	"crypto/tls"
+	"io"
 	"log/slog"
 	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -41,9 +43,27 @@ import (
 type sshProvider interface {
 	ResolveSSHInfo(ctx context.Context, fqdn string) (*vnetv1.SshInfo, error)
 	TeleportClientTLSConfig(ctx context.Context, profileName, clusterName string) (*tls.Config, error)
-	UserSSHConfig(ctx context.Context, sshInfo *vnetv1.SshInfo, username string) (*ssh.ClientConfig, error)
+	// UserSSHConfig returns a client config for dialing sshInfo as username,
+	// along with the expiry of the cert it authenticates with (the zero
+	// Time if the cert never expires).
+	UserSSHConfig(ctx context.Context, sshInfo *vnetv1.SshInfo, username string) (*ssh.ClientConfig, time.Time, error)
+	AgentForwardingPermitted(ctx context.Context, sshInfo *vnetv1.SshInfo) bool
+	OnSFTPRequest(ctx context.Context, sshInfo *vnetv1.SshInfo, req *SFTPFileRequest) error
+	SSHPasswordFallbackConfig(ctx context.Context) (enabled bool, usernameSuffix string)
+	SSHSessionRecordingConfig(ctx context.Context, sshInfo *vnetv1.SshInfo) bool
+	RecordSSHSessionEvent(ctx context.Context, sshInfo *vnetv1.SshInfo, event *SSHSessionRecordingEvent) error
 }
 
+const (
+	// agentForwardingRequestType is sent by an SSH client to request that its
+	// local SSH agent be forwarded for the lifetime of the session, allowing
+	// e.g. `ssh -A` to work transparently through VNet.
+	agentForwardingRequestType = "auth-agent-req@openssh.com"
+	// agentChannelType is the channel type used to carry forwarded SSH agent
+	// protocol messages.
+	agentChannelType = "auth-agent@openssh.com"
+)
+
 type sshResolver struct {
 	sshProvider sshProvider
 	log         *slog.Logger
@@ -85,6 +105,7 @@ func (r *sshResolver) newSSHHandler(ctx context.Context, sshInfo *vnetv1.SshInfo
 		sshInfo:     sshInfo,
 		sshProvider: r.sshProvider,
 		hostSigner:  r.hostSigner,
+		clientPool:  newSSHClientPool(r.clock),
 	}
 }
 
@@ -95,15 +116,15 @@ type sshHandler struct {
 
 	fg              singleflight.Group
 	sshClientConfig sync.Map
+
+	// clientPool holds live upstream *ssh.Client connections, keyed by the
+	// remote username, so that many short-lived incoming connections to the
+	// same target reuse an existing SSH connection instead of each paying
+	// for their own TCP dial and handshake.
+	clientPool *sshClientPool
 }
 
 func (h *sshHandler) handleTCPConnector(ctx context.Context, localPort uint16, connector func() (net.Conn, error)) error {
-	targetTCPConn, err := h.dialTargetTCP(ctx)
-	if err != nil {
-		return trace.Wrap(err, "dialing SSH host %s", h.sshInfo.SshKey.Hostname)
-	}
-	defer targetTCPConn.Close()
-
 	localTCPConn, err := connector()
 	if err != nil {
 		return trace.Wrap(err, "unwrapping local VNet TCP conn")
@@ -111,36 +132,196 @@ func (h *sshHandler) handleTCPConnector(ctx context.Context, localPort uint16, c
 	defer localTCPConn.Close()
 
 	var targetClient *ssh.Client
+	var targetRelease func(healthy bool)
+	var username string
 	var preAuthConn ssh.ServerPreAuthConn
+
+	// authenticate dials the target as remoteUsername and, on success,
+	// records it as the authenticated user of this connection. It's shared
+	// by every auth method VNet's SSH listener accepts, since they all
+	// ultimately just need to agree on who to dial as and VNet's real trust
+	// boundary is the local-loopback connection plus the user's downstream
+	// Teleport cert, not anything the SSH client presents here.
+	authenticate := func(remoteUsername string) (*ssh.Permissions, error) {
+		username = remoteUsername
+		var err error
+		targetClient, targetRelease, err = h.getOrDialTarget(ctx, username)
+		if err != nil {
+			err = trace.Wrap(err, "dialing target node")
+			go func() { preAuthConn.SendAuthBanner(err.Error()) }()
+			time.Sleep(500 * time.Millisecond)
+			return nil, err
+		}
+		return nil, nil
+	}
+
 	serverConfig := &ssh.ServerConfig{
 		PreAuthConnCallback: func(conn ssh.ServerPreAuthConn) {
 			preAuthConn = conn
 		},
 		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
-			targetClient, err = h.dialTargetSSH(ctx, targetTCPConn, conn.User())
+			return authenticate(conn.User())
+		},
+	}
+
+	// Some SSH clients (older PuTTY builds, some libssh-based tools) get
+	// confused by VNet's publickey-only auth and never connect at all.
+	// Following the technique Tailscale's tailssh uses for the same
+	// problem, clients can opt an individual connection into password or
+	// keyboard-interactive auth by appending a configurable suffix to their
+	// username; VNet strips it and accepts any credential offered, since
+	// the real authentication is the cert dialTargetSSH uses against the
+	// target node.
+	if enabled, suffix := h.sshProvider.SSHPasswordFallbackConfig(ctx); enabled && suffix != "" {
+		fallbackUsername := func(conn ssh.ConnMetadata) (string, error) {
+			target, ok := strings.CutSuffix(conn.User(), suffix)
+			if !ok {
+				return "", trace.AccessDenied("password auth is only permitted for usernames ending in %q", suffix)
+			}
+			return target, nil
+		}
+		serverConfig.PasswordCallback = func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			target, err := fallbackUsername(conn)
 			if err != nil {
-				err = trace.Wrap(err, "dialing target node")
-				go func() { preAuthConn.SendAuthBanner(err.Error()) }()
-				time.Sleep(500 * time.Millisecond)
-				return nil, err
+				return nil, trace.Wrap(err)
 			}
-			return nil, nil
-		},
+			return authenticate(target)
+		}
+		serverConfig.KeyboardInteractiveCallback = func(conn ssh.ConnMetadata, _ ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			target, err := fallbackUsername(conn)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			return authenticate(target)
+		}
 	}
+
 	serverConfig.AddHostKey(h.hostSigner)
 	serverConn, chans, requests, err := ssh.NewServerConn(localTCPConn, serverConfig)
 	if err != nil {
-		if targetClient != nil {
-			targetClient.Close()
+		// The incoming conn failed, not targetClient, which may still be
+		// serving other connections sharing the same pooled client.
+		if targetRelease != nil {
+			targetRelease(true)
 		}
 		return trace.Wrap(err, "accepting incoming SSH conn")
 	}
-	defer func() {
-		serverConn.Close()
-		targetClient.Close()
+	defer serverConn.Close()
+
+	requests = h.interceptAgentForwarding(ctx, serverConn, targetClient, requests)
+	chans = h.interceptSFTPSubsystem(ctx, targetClient, chans)
+	chans = h.interceptSessionRecording(ctx, chans)
+
+	err = proxySSHConnection(ctx, targetClient, chans, requests)
+	// Report targetClient's health back to the pool rather than closing it
+	// directly: it may still be in use by other connections sharing the
+	// same pooled client, and the pool only actually closes it once every
+	// holder has released it.
+	if targetRelease != nil {
+		targetRelease(err == nil)
+	}
+	return trace.Wrap(err, "proxying SSH connection")
+}
+
+// poolKey returns the sshClientPool key for a connection to this handler's
+// target as username.
+func (h *sshHandler) poolKey(username string) sshClientPoolKey {
+	return sshClientPoolKey{
+		username: username,
+		cluster:  h.sshInfo.Cluster,
+		hostname: h.sshInfo.SshKey.Hostname,
+	}
+}
+
+// getOrDialTarget returns a pooled upstream SSH client for username, dialing
+// a fresh one while the pool holds fewer than sshClientPoolMaxPerKey clients
+// for it and otherwise sharing the least-recently-used one. The returned
+// release func must be called exactly once, with whether targetClient is
+// still healthy, when the caller is done with it.
+func (h *sshHandler) getOrDialTarget(ctx context.Context, username string) (targetClient *ssh.Client, release func(healthy bool), err error) {
+	return h.clientPool.get(ctx, h.poolKey(username), func(ctx context.Context) (*ssh.Client, time.Time, error) {
+		return h.dialTargetSSH(ctx, username)
+	})
+}
+
+// interceptAgentForwarding filters requests for agentForwardingRequestType
+// out of requests, handling them itself and proxying every other request
+// through unmodified. A client requesting agent forwarding expects the
+// target node to be able to open agentChannelType channels back to it for
+// the lifetime of the session, so on success this also starts relaying any
+// such channels the target opens on targetClient back to localConn.
+func (h *sshHandler) interceptAgentForwarding(ctx context.Context, localConn ssh.Conn, targetClient *ssh.Client, requests <-chan *ssh.Request) <-chan *ssh.Request {
+	out := make(chan *ssh.Request)
+	go func() {
+		defer close(out)
+		for req := range requests {
+			if req.Type != agentForwardingRequestType {
+				out <- req
+				continue
+			}
+			h.handleAgentForwardingRequest(ctx, localConn, targetClient, req)
+		}
 	}()
+	return out
+}
+
+func (h *sshHandler) handleAgentForwardingRequest(ctx context.Context, localConn ssh.Conn, targetClient *ssh.Client, req *ssh.Request) {
+	allowed := h.sshProvider.AgentForwardingPermitted(ctx, h.sshInfo)
+	if req.WantReply {
+		if err := req.Reply(allowed, nil); err != nil {
+			log.DebugContext(ctx, "Failed to reply to agent forwarding request", "error", err)
+		}
+	}
+	if !allowed {
+		log.DebugContext(ctx, "Refusing to forward SSH agent", "target", h.sshInfo.SshKey.Hostname)
+		return
+	}
 
-	return trace.Wrap(proxySSHConnection(ctx, targetClient, chans, requests), "proxying SSH connection")
+	agentChannels := targetClient.HandleChannelOpen(agentChannelType)
+	go h.proxyAgentChannels(ctx, localConn, agentChannels)
+}
+
+// proxyAgentChannels bridges every agentChannelType channel opened by the
+// target node to a corresponding channel opened back to localConn, which is
+// expected to service it using the local user's SSH agent.
+func (h *sshHandler) proxyAgentChannels(ctx context.Context, localConn ssh.Conn, agentChannels <-chan ssh.NewChannel) {
+	for newChannel := range agentChannels {
+		targetAgentChan, targetReqs, err := newChannel.Accept()
+		if err != nil {
+			log.WarnContext(ctx, "Failed to accept SSH agent forwarding channel from target", "error", err)
+			continue
+		}
+		go ssh.DiscardRequests(targetReqs)
+
+		localAgentChan, localReqs, err := localConn.OpenChannel(agentChannelType, nil)
+		if err != nil {
+			log.WarnContext(ctx, "Local SSH client rejected SSH agent forwarding channel", "error", err)
+			targetAgentChan.Close()
+			continue
+		}
+		go ssh.DiscardRequests(localReqs)
+
+		go proxyAgentChannel(targetAgentChan, localAgentChan)
+	}
+}
+
+// proxyAgentChannel copies SSH agent protocol messages bidirectionally
+// between two agentChannelType channels until either side closes.
+func proxyAgentChannel(a, b ssh.Channel) {
+	defer a.Close()
+	defer b.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(a, b)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(b, a)
+	}()
+	wg.Wait()
 }
 
 func (h *sshHandler) dialTargetTCP(ctx context.Context) (net.Conn, error) {
@@ -169,59 +350,71 @@ func (h *sshHandler) dialTargetTCP(ctx context.Context) (net.Conn, error) {
 	return targetConn, trace.Wrap(err)
 }
 
-func (h *sshHandler) dialTargetSSH(ctx context.Context, tcpConn net.Conn, username string) (*ssh.Client, error) {
-	sshClientConfig, err := h.userSSHConfig(ctx, username)
+// sshClientConfigEntry bundles a cached client config with the expiry of
+// the cert it authenticates with, so callers can proactively evict pooled
+// clients whose cert has expired instead of waiting for a handshake to
+// fail.
+type sshClientConfigEntry struct {
+	config *ssh.ClientConfig
+	expiry time.Time
+}
+
+func (h *sshHandler) dialTargetSSH(ctx context.Context, username string) (*ssh.Client, time.Time, error) {
+	tcpConn, err := h.dialTargetTCP(ctx)
 	if err != nil {
-		return nil, trace.Wrap(err, "getting user SSH client config")
+		return nil, time.Time{}, trace.Wrap(err, "dialing SSH host %s", h.sshInfo.SshKey.Hostname)
 	}
-	sshconn, chans, reqs, err := tracessh.NewClientConn(ctx, tcpConn, h.sshInfo.SshKey.Hostname, sshClientConfig)
+	entry, err := h.userSSHConfig(ctx, username)
+	if err != nil {
+		tcpConn.Close()
+		return nil, time.Time{}, trace.Wrap(err, "getting user SSH client config")
+	}
+	sshconn, chans, reqs, err := tracessh.NewClientConn(ctx, tcpConn, h.sshInfo.SshKey.Hostname, entry.config)
 	if err != nil {
 		log.InfoContext(ctx, "Error dialing target SSH node, retrying with a fresh user cert", "error", err)
-		sshClient, err := h.retryDialTargetSSH(ctx, username)
-		return sshClient, trace.Wrap(err)
+		sshClient, expiry, err := h.retryDialTargetSSH(ctx, username)
+		return sshClient, expiry, trace.Wrap(err)
 	}
 	log.DebugContext(ctx, "Dialed target SSH node", "target", h.sshInfo.SshKey.Hostname)
-	return ssh.NewClient(sshconn, chans, reqs), nil
+	return ssh.NewClient(sshconn, chans, reqs), entry.expiry, nil
 }
 
-func (h *sshHandler) retryDialTargetSSH(ctx context.Context, username string) (*ssh.Client, error) {
+func (h *sshHandler) retryDialTargetSSH(ctx context.Context, username string) (*ssh.Client, time.Time, error) {
 	h.sshClientConfig.Delete(username)
-	sshClientConfig, err := h.userSSHConfig(ctx, username)
+	entry, err := h.userSSHConfig(ctx, username)
 	if err != nil {
-		return nil, trace.Wrap(err, "getting fresh SSH client config")
+		return nil, time.Time{}, trace.Wrap(err, "getting fresh SSH client config")
 	}
 	// We need a fresh TCP connection to the target.
 	tcpConn, err := h.dialTargetTCP(ctx)
 	if err != nil {
-		return nil, trace.Wrap(err, "redialing target with fresh SSH cert")
+		return nil, time.Time{}, trace.Wrap(err, "redialing target with fresh SSH cert")
 	}
-	sshconn, chans, reqs, err := tracessh.NewClientConn(ctx, tcpConn, h.sshInfo.SshKey.Hostname, sshClientConfig)
+	sshconn, chans, reqs, err := tracessh.NewClientConn(ctx, tcpConn, h.sshInfo.SshKey.Hostname, entry.config)
 	if err != nil {
-		return nil, trace.Wrap(err, "dialing target SSH node with fresh user cert")
+		return nil, time.Time{}, trace.Wrap(err, "dialing target SSH node with fresh user cert")
 	}
-	return ssh.NewClient(sshconn, chans, reqs), nil
+	return ssh.NewClient(sshconn, chans, reqs), entry.expiry, nil
 }
 
-func (h *sshHandler) userSSHConfig(ctx context.Context, username string) (*ssh.ClientConfig, error) {
+func (h *sshHandler) userSSHConfig(ctx context.Context, username string) (*sshClientConfigEntry, error) {
 	if c, ok := h.sshClientConfig.Load(username); ok {
-		return c.(*ssh.ClientConfig), nil
+		return c.(*sshClientConfigEntry), nil
 	}
 	_, err, _ := h.fg.Do(username, func() (any, error) {
-		if c, ok := h.sshClientConfig.Load(username); ok {
-			return c.(*ssh.ClientConfig), nil
+		if _, ok := h.sshClientConfig.Load(username); ok {
+			return nil, nil
 		}
-		c, err := h.sshProvider.UserSSHConfig(ctx, h.sshInfo, username)
+		config, expiry, err := h.sshProvider.UserSSHConfig(ctx, h.sshInfo, username)
 		if err != nil {
 			return nil, trace.Wrap(err, "getting user SSH client config")
 		}
-		h.sshClientConfig.Store(username, c)
+		h.sshClientConfig.Store(username, &sshClientConfigEntry{config: config, expiry: expiry})
 		return nil, nil
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	c, ok := h.sshClientConfig.Load(username)
-	if !ok {
-	}
-	return c.(*ssh.ClientConfig), nil
+	c, _ := h.sshClientConfig.Load(username)
+	return c.(*sshClientConfigEntry), nil
 }
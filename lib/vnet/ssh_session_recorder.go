@@ -0,0 +1,239 @@
+// Teleport
+// Copyright (C) 2025 Gravitational, Inc.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package vnet
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHSessionRecordingEventType identifies the kind of event described by an
+// SSHSessionRecordingEvent.
+type SSHSessionRecordingEventType string
+
+const (
+	// SSHSessionRecordingEventStart marks the beginning of a recorded
+	// session channel.
+	SSHSessionRecordingEventStart SSHSessionRecordingEventType = "start"
+	// SSHSessionRecordingEventEnd marks the end of a recorded session
+	// channel.
+	SSHSessionRecordingEventEnd SSHSessionRecordingEventType = "end"
+	// SSHSessionRecordingEventPrint carries a chunk of the session's raw,
+	// interleaved terminal I/O, in the order it was observed, for playback.
+	SSHSessionRecordingEventPrint SSHSessionRecordingEventType = "print"
+	// SSHSessionRecordingEventResize records a pty-req or window-change
+	// request changing the session's terminal dimensions.
+	SSHSessionRecordingEventResize SSHSessionRecordingEventType = "resize"
+	// SSHSessionRecordingEventExec records an exec request.
+	SSHSessionRecordingEventExec SSHSessionRecordingEventType = "exec"
+	// SSHSessionRecordingEventShell records a shell request.
+	SSHSessionRecordingEventShell SSHSessionRecordingEventType = "shell"
+	// SSHSessionRecordingEventSubsystem records a subsystem request.
+	SSHSessionRecordingEventSubsystem SSHSessionRecordingEventType = "subsystem"
+)
+
+// SSHSessionRecordingEvent describes a single event observed on a recorded
+// SSH session channel proxied through VNet, passed to
+// ClientApplication.RecordSSHSessionEvent for emission into the
+// session-recording backend.
+type SSHSessionRecordingEvent struct {
+	// Type is the kind of event this is.
+	Type SSHSessionRecordingEventType
+	// Data holds a chunk of raw terminal I/O for a Print event.
+	Data []byte
+	// Command is the command line for an Exec event.
+	Command string
+	// Subsystem is the subsystem name for a Subsystem event.
+	Subsystem string
+	// Width and Height are the terminal dimensions, in characters, for a
+	// Resize event.
+	Width, Height uint32
+}
+
+// ptyRequestPayload mirrors the payload of a "pty-req" channel request, as
+// defined in RFC 4254 section 6.2.
+type ptyRequestPayload struct {
+	Term      string
+	Width     uint32
+	Height    uint32
+	PixWidth  uint32
+	PixHeight uint32
+	Modes     string
+}
+
+// windowChangeRequestPayload mirrors the payload of a "window-change"
+// channel request, as defined in RFC 4254 section 6.7.
+type windowChangeRequestPayload struct {
+	Width     uint32
+	Height    uint32
+	PixWidth  uint32
+	PixHeight uint32
+}
+
+// execRequestPayload mirrors the payload of an "exec" channel request, as
+// defined in RFC 4254 section 6.5.
+type execRequestPayload struct {
+	Command string
+}
+
+// interceptSessionRecording wraps every incoming "session" channel so that,
+// if ClientApplication.SSHSessionRecordingConfig enables recording for this
+// connection, the pty-req/window-change/exec/shell/subsystem requests seen
+// on it are captured as structured SSHSessionRecordingEvents and all data
+// read from or written to the channel is recorded as well, alongside being
+// forwarded exactly as it would be otherwise. Recording only observes; it
+// never changes what's forwarded to the target node. When recording is
+// disabled, chans is returned unmodified.
+func (h *sshHandler) interceptSessionRecording(ctx context.Context, chans <-chan ssh.NewChannel) <-chan ssh.NewChannel {
+	if !h.sshProvider.SSHSessionRecordingConfig(ctx, h.sshInfo) {
+		return chans
+	}
+
+	out := make(chan ssh.NewChannel)
+	go func() {
+		defer close(out)
+		for newChannel := range chans {
+			if newChannel.ChannelType() != "session" {
+				out <- newChannel
+				continue
+			}
+			out <- &sessionRecordingNewChannel{NewChannel: newChannel, h: h, ctx: ctx}
+		}
+	}()
+	return out
+}
+
+// sessionRecordingNewChannel wraps an ssh.NewChannel of type "session" so
+// that, once accepted, its requests and data are recorded for the lifetime
+// of the channel.
+type sessionRecordingNewChannel struct {
+	ssh.NewChannel
+	h   *sshHandler
+	ctx context.Context
+}
+
+func (c *sessionRecordingNewChannel) Accept() (ssh.Channel, <-chan *ssh.Request, error) {
+	channel, requests, err := c.NewChannel.Accept()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.h.recordSessionEvent(c.ctx, &SSHSessionRecordingEvent{Type: SSHSessionRecordingEventStart})
+
+	out := make(chan *ssh.Request)
+	go c.h.watchSessionRequests(c.ctx, requests, out)
+	return &sessionRecordingChannel{Channel: channel, h: c.h, ctx: c.ctx}, out, nil
+}
+
+// sessionRecordingChannel wraps an ssh.Channel so every Read and Write is
+// additionally recorded as an SSHSessionRecordingEventPrint event, and the
+// channel's close is recorded as SSHSessionRecordingEventEnd.
+type sessionRecordingChannel struct {
+	ssh.Channel
+	h        *sshHandler
+	ctx      context.Context
+	closeOne sync.Once
+}
+
+func (c *sessionRecordingChannel) Read(p []byte) (int, error) {
+	n, err := c.Channel.Read(p)
+	if n > 0 {
+		c.h.recordSessionEvent(c.ctx, &SSHSessionRecordingEvent{
+			Type: SSHSessionRecordingEventPrint,
+			Data: append([]byte(nil), p[:n]...),
+		})
+	}
+	return n, err
+}
+
+func (c *sessionRecordingChannel) Write(p []byte) (int, error) {
+	n, err := c.Channel.Write(p)
+	if n > 0 {
+		c.h.recordSessionEvent(c.ctx, &SSHSessionRecordingEvent{
+			Type: SSHSessionRecordingEventPrint,
+			Data: append([]byte(nil), p[:n]...),
+		})
+	}
+	return n, err
+}
+
+func (c *sessionRecordingChannel) Close() error {
+	c.closeOne.Do(func() {
+		c.h.recordSessionEvent(c.ctx, &SSHSessionRecordingEvent{Type: SSHSessionRecordingEventEnd})
+	})
+	return c.Channel.Close()
+}
+
+// watchSessionRequests relays requests from in to out unmodified, recording
+// a structured event for each pty-req, window-change, exec, shell, or
+// subsystem request it sees along the way.
+func (h *sshHandler) watchSessionRequests(ctx context.Context, in <-chan *ssh.Request, out chan<- *ssh.Request) {
+	defer close(out)
+	for req := range in {
+		if event := parseSessionRequestEvent(req); event != nil {
+			h.recordSessionEvent(ctx, event)
+		}
+		out <- req
+	}
+}
+
+// parseSessionRequestEvent returns the SSHSessionRecordingEvent described by
+// req, or nil if req isn't a request type worth recording, or its payload
+// can't be parsed.
+func parseSessionRequestEvent(req *ssh.Request) *SSHSessionRecordingEvent {
+	switch req.Type {
+	case "pty-req":
+		var payload ptyRequestPayload
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			return nil
+		}
+		return &SSHSessionRecordingEvent{Type: SSHSessionRecordingEventResize, Width: payload.Width, Height: payload.Height}
+	case "window-change":
+		var payload windowChangeRequestPayload
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			return nil
+		}
+		return &SSHSessionRecordingEvent{Type: SSHSessionRecordingEventResize, Width: payload.Width, Height: payload.Height}
+	case "exec":
+		var payload execRequestPayload
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			return nil
+		}
+		return &SSHSessionRecordingEvent{Type: SSHSessionRecordingEventExec, Command: payload.Command}
+	case "shell":
+		return &SSHSessionRecordingEvent{Type: SSHSessionRecordingEventShell}
+	case "subsystem":
+		var payload subsystemRequestPayload
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			return nil
+		}
+		return &SSHSessionRecordingEvent{Type: SSHSessionRecordingEventSubsystem, Subsystem: payload.Subsystem}
+	default:
+		return nil
+	}
+}
+
+// recordSessionEvent forwards event to ClientApplication for emission into
+// the session-recording backend, logging rather than failing the session on
+// error since recording is a best-effort addition on top of proxying.
+func (h *sshHandler) recordSessionEvent(ctx context.Context, event *SSHSessionRecordingEvent) {
+	if err := h.sshProvider.RecordSSHSessionEvent(ctx, h.sshInfo, event); err != nil {
+		log.DebugContext(ctx, "Failed to record SSH session event", "type", event.Type, "error", err)
+	}
+}
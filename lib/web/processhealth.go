@@ -19,65 +19,313 @@
 package web
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/gravitational/trace"
 	"github.com/julienschmidt/httprouter"
 
+	processhealthv1 "github.com/gravitational/teleport/api/gen/proto/go/teleport/processhealth/v1"
 	"github.com/gravitational/teleport/lib/reversetunnelclient"
 )
 
+const (
+	processHealthStatusOK       = "ok"
+	processHealthStatusDegraded = "degraded"
+	processHealthStatusFailed   = "failed"
+
+	// defaultProcessHealthLimit is the page size returned to the UI when the
+	// caller doesn't specify one.
+	defaultProcessHealthLimit = 100
+
+	// maxProcessHealthScanPages bounds how many backend pages a single
+	// request will scan looking for matches, so a fleet where almost every
+	// host is filtered out can't turn one HTTP request into an unbounded
+	// full-table scan.
+	maxProcessHealthScanPages = 20
+)
+
+// processHealthList serves a page of ProcessHealth records, applying
+// search/status filtering and sorting server-side so the UI never has to
+// pull the whole fleet into the browser to page through it.
 func (h *Handler) processHealthList(w http.ResponseWriter, r *http.Request, p httprouter.Params, sctx *SessionContext, site reversetunnelclient.RemoteSite) (interface{}, error) {
 	clt, err := sctx.GetUserClient(r.Context(), site)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	phs, _, err := clt.ProcessHealthClient().ListProcessHealths(r.Context(), 0, "")
+
+	q := r.URL.Query()
+
+	limit := defaultProcessHealthLimit
+	if v := q.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return nil, trace.BadParameter("limit must be a positive integer")
+		}
+	}
+
+	search := strings.ToLower(strings.TrimSpace(q.Get("search")))
+
+	unitStatus := q.Get("unitStatus")
+	switch unitStatus {
+	case "", processHealthStatusOK, processHealthStatusDegraded, processHealthStatusFailed:
+	default:
+		return nil, trace.BadParameter("unitStatus must be one of %q, %q or %q", processHealthStatusOK, processHealthStatusDegraded, processHealthStatusFailed)
+	}
+
+	phClient := clt.ProcessHealthClient()
+
+	var items []ProcessHealth
+	startKey := q.Get("startKey")
+	var backendNextKey string
+	for page := 0; ; page++ {
+		phs, nextToken, err := phClient.ListProcessHealths(r.Context(), 0, startKey)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+
+		for _, ph := range phs {
+			item := processHealthFromProto(ph)
+			if matchesProcessHealthFilter(item, search, unitStatus) {
+				items = append(items, item)
+			}
+		}
+
+		backendNextKey = nextToken
+		if backendNextKey == "" || len(items) >= limit || page+1 >= maxProcessHealthScanPages {
+			break
+		}
+		startKey = backendNextKey
+	}
+
+	var nextKey string
+	if len(items) > limit {
+		// The backend resumes a listing at, and including, its start key, so
+		// resuming from the HostID of the first item we're trimming off
+		// continues exactly where this page left off. Items are trimmed
+		// before sorting, while they're still in backend (HostID) order.
+		nextKey = items[limit].HostID
+		items = items[:limit]
+	} else {
+		nextKey = backendNextKey
+	}
+
+	sortProcessHealths(items, q.Get("sort"))
+
+	resp := ProcessHealthReponse{
+		Items:   items,
+		NextKey: nextKey,
+	}
+	if resp.Items == nil {
+		resp.Items = []ProcessHealth{}
+	}
+
+	return resp, nil
+}
+
+// processHealthWatch serves GET /webapi/sites/:site/processhealth/watch as
+// a Server-Sent Events stream: an initial snapshot of every ProcessHealth
+// record, each sent as its own "put" event, followed by a live feed of
+// "put"/"delete" events as the fleet changes. Every event's SSE id is the
+// ProcessHealth's HostID, so a client that reconnects with a Last-Event-ID
+// header (sent automatically by EventSource) resumes from where it left
+// off instead of re-reading the whole fleet.
+func (h *Handler) processHealthWatch(w http.ResponseWriter, r *http.Request, p httprouter.Params, sctx *SessionContext, site reversetunnelclient.RemoteSite) (interface{}, error) {
+	clt, err := sctx.GetUserClient(r.Context(), site)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
-	resp := ProcessHealthReponse{
-		Items: make([]ProcessHealth, 0, len(phs)),
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, trace.BadParameter("streaming not supported")
+	}
+
+	phClient := clt.ProcessHealthClient()
+	ctx := r.Context()
+
+	watcher, err := phClient.WatchProcessHealths(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
 	}
+	defer watcher.Close()
 
-	for _, ph := range phs {
-		units := make([]ProcessHealthUnit, 0, len(ph.Status.UnitsByName))
-		for unitName, unit := range ph.Status.UnitsByName {
-			units = append(units, ProcessHealthUnit{
-				Name:   unitName,
-				Status: unit.State,
-			})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	startKey := r.Header.Get("Last-Event-ID")
+	if startKey == "" {
+		startKey = r.URL.Query().Get("startKey")
+	}
+
+	for {
+		phs, nextToken, err := phClient.ListProcessHealths(ctx, 0, startKey)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, ph := range phs {
+			if err := writeProcessHealthSSEEvent(w, flusher, "put", processHealthFromProto(ph)); err != nil {
+				return nil, trace.Wrap(err)
+			}
+		}
+		if nextToken == "" {
+			break
 		}
+		startKey = nextToken
+	}
 
-		uptime := ph.Status.SystemInfo.ProcessUptime
-		uptimeSince := time.Now().Add(-time.Second * time.Duration(uptime))
+	for {
+		select {
+		case ph, ok := <-watcher.Events():
+			if !ok {
+				return nil, trace.Wrap(watcher.Error())
+			}
+			// The api-client watcher yields a bare *ProcessHealth with no
+			// put/delete marker; deletes only carry their resource header
+			// (see lib/cache's processHealthWatcher), so a nil Status is
+			// how we tell the two apart here.
+			eventName := "put"
+			item := ProcessHealth{HostID: ph.GetMetadata().GetName()}
+			if ph.GetStatus() == nil {
+				eventName = "delete"
+			} else {
+				item = processHealthFromProto(ph)
+			}
+			if err := writeProcessHealthSSEEvent(w, flusher, eventName, item); err != nil {
+				return nil, trace.Wrap(err)
+			}
+		case <-watcher.Done():
+			return nil, trace.Wrap(watcher.Error())
+		case <-ctx.Done():
+			return nil, nil
+		}
+	}
+}
 
-		resp.Items = append(resp.Items, ProcessHealth{
-			HostID:  ph.Metadata.Name,
-			Uptime:  humanize.RelTime(uptimeSince, time.Now(), "ago", "from now"),
-			Version: ph.Version,
-			Units:   units,
+// writeProcessHealthSSEEvent writes ph as a single SSE frame named event,
+// using its HostID as the event id (for Last-Event-ID resume).
+func writeProcessHealthSSEEvent(w http.ResponseWriter, flusher http.Flusher, event string, ph ProcessHealth) error {
+	data, err := json.Marshal(ph)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", ph.HostID, event, data); err != nil {
+		return trace.Wrap(err)
+	}
+	flusher.Flush()
+	return nil
+}
+
+// processHealthFromProto converts the gRPC representation into the shape
+// served to the UI, additionally computing the overall unit status used for
+// the unitStatus filter and the failing_units sort.
+func processHealthFromProto(ph *processhealthv1.ProcessHealth) ProcessHealth {
+	units := make([]ProcessHealthUnit, 0, len(ph.Status.UnitsByName))
+	allOK := true
+	anyFailed := false
+	for unitName, unit := range ph.Status.UnitsByName {
+		units = append(units, ProcessHealthUnit{
+			Name:   unitName,
+			Status: unit.State,
 		})
+		if unit.State != processHealthStatusOK {
+			allOK = false
+		}
+		if unit.State == processHealthStatusFailed {
+			anyFailed = true
+		}
 	}
 
-	return resp, nil
+	unitStatus := processHealthStatusOK
+	switch {
+	case anyFailed:
+		unitStatus = processHealthStatusFailed
+	case !allOK:
+		unitStatus = processHealthStatusDegraded
+	}
+
+	uptime := ph.Status.SystemInfo.ProcessUptime
+	uptimeSince := time.Now().Add(-time.Second * time.Duration(uptime))
+
+	return ProcessHealth{
+		HostID:        ph.Metadata.Name,
+		Uptime:        humanize.RelTime(uptimeSince, time.Now(), "ago", "from now"),
+		Version:       ph.Version,
+		UnitStatus:    unitStatus,
+		Units:         units,
+		uptimeSeconds: uptime,
+	}
 }
 
 type ProcessHealthReponse struct {
-	Items []ProcessHealth
+	Items   []ProcessHealth
+	NextKey string
 }
 
 type ProcessHealth struct {
-	HostID  string
-	Uptime  string
-	Version string
-	Units   []ProcessHealthUnit
+	HostID     string
+	Uptime     string
+	Version    string
+	UnitStatus string
+	Units      []ProcessHealthUnit
+
+	uptimeSeconds int64
 }
 
 type ProcessHealthUnit struct {
 	Name   string
 	Status string
 }
+
+// matchesProcessHealthFilter reports whether ph satisfies the search
+// substring (matched against HostID and Version) and unitStatus filters.
+func matchesProcessHealthFilter(ph ProcessHealth, search, unitStatus string) bool {
+	if search != "" {
+		if !strings.Contains(strings.ToLower(ph.HostID), search) &&
+			!strings.Contains(strings.ToLower(ph.Version), search) {
+			return false
+		}
+	}
+	if unitStatus != "" && ph.UnitStatus != unitStatus {
+		return false
+	}
+	return true
+}
+
+// sortProcessHealths sorts items in place by the requested field. Sorting
+// only covers the page of items already fetched from the backend, since
+// sorting the full fleet would mean reading it entirely into memory.
+func sortProcessHealths(items []ProcessHealth, by string) {
+	switch by {
+	case "uptime":
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].uptimeSeconds < items[j].uptimeSeconds
+		})
+	case "failing_units":
+		sort.SliceStable(items, func(i, j int) bool {
+			return countFailingUnits(items[i]) > countFailingUnits(items[j])
+		})
+	case "hostid", "":
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].HostID < items[j].HostID
+		})
+	}
+}
+
+func countFailingUnits(ph ProcessHealth) int {
+	var n int
+	for _, u := range ph.Units {
+		if u.Status != processHealthStatusOK {
+			n++
+		}
+	}
+	return n
+}
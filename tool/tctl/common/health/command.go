@@ -18,28 +18,69 @@ package health
 
 import (
 	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/gravitational/trace"
+	"gopkg.in/yaml.v2"
 
+	"github.com/gravitational/teleport/api/client/processhealth"
+	processhealthv1 "github.com/gravitational/teleport/api/gen/proto/go/teleport/processhealth/v1"
 	"github.com/gravitational/teleport/lib/service/servicecfg"
+	"github.com/gravitational/teleport/lib/services"
 	commonclient "github.com/gravitational/teleport/tool/tctl/common/client"
 	tctlcfg "github.com/gravitational/teleport/tool/tctl/common/config"
 )
 
+const (
+	formatText = "text"
+	formatJSON = "json"
+	formatYAML = "yaml"
+
+	// defaultWatchInterval is how often --watch re-polls when none is given.
+	defaultWatchInterval = 5 * time.Second
+
+	// defaultProfileDuration is how long a "cpu" profile samples for when
+	// --profile doesn't specify a duration.
+	defaultProfileDuration = 30 * time.Second
+)
+
 // Command is a debug command that consumes the
 // Teleport /metrics endpoint and displays diagnostic
 // information an easy to consume way.
 type Command struct {
 	config *servicecfg.Config
 	top    *kingpin.CmdClause
+
+	format        string
+	output        string
+	watch         bool
+	watchInterval time.Duration
+	profile       string
+	profileHost   string
 }
 
 // Initialize sets up the "tctl top" command.
 func (c *Command) Initialize(app *kingpin.Application, _ *tctlcfg.GlobalCLIFlags, config *servicecfg.Config) {
 	c.config = config
 	c.top = app.Command("health", "Process Health.")
+	c.top.Flag("format", "Output format: text (interactive TUI), json, or yaml (machine-readable snapshot).").
+		Default(formatText).EnumVar(&c.format, formatText, formatJSON, formatYAML)
+	c.top.Flag("out", "Write a snapshot to this file instead of stdout. Implies --format=json.").
+		StringVar(&c.output)
+	c.top.Flag("watch", "Stream a new snapshot every --watch-interval instead of exiting after one. Only valid with --format=json or --format=yaml.").
+		BoolVar(&c.watch)
+	c.top.Flag("watch-interval", "How often to take a new snapshot with --watch.").
+		Default(defaultWatchInterval.String()).DurationVar(&c.watchInterval)
+	c.top.Flag("profile", "Capture a pprof profile instead of a health snapshot: cpu|heap|goroutine, optionally suffixed with a capture duration, e.g. cpu,1m.").
+		StringVar(&c.profile)
+	c.top.Flag("profile-host", "HostID to capture --profile from. Defaults to the auth service handling the request.").
+		StringVar(&c.profileHost)
 }
 
 // TryRun attempts to run subcommands.
@@ -54,6 +95,21 @@ func (c *Command) TryRun(ctx context.Context, cmd string, clientFunc commonclien
 	}
 	defer closeFn(ctx)
 
+	if c.profile != "" {
+		return true, trace.Wrap(c.captureProfile(ctx, client.ProcessHealthClient()))
+	}
+
+	if c.watch {
+		if c.format != formatJSON && c.format != formatYAML {
+			return false, trace.BadParameter("--watch requires --format=json or --format=yaml")
+		}
+		return true, trace.Wrap(c.watchSnapshots(ctx, client.ProcessHealthClient()))
+	}
+
+	if c.output != "" || c.format == formatJSON || c.format == formatYAML {
+		return true, trace.Wrap(c.capture(ctx, client.ProcessHealthClient()))
+	}
+
 	m, err := initHostsTable(ctx, client.ProcessHealthClient())
 	if err != nil {
 		return false, trace.Wrap(err)
@@ -62,3 +118,111 @@ func (c *Command) TryRun(ctx context.Context, cmd string, clientFunc commonclien
 	_, err = tea.NewProgram(m).Run()
 	return true, trace.Wrap(err)
 }
+
+// capture fetches every ProcessHealth resource and writes it as a JSON or
+// YAML array, either to c.output or to stdout, for scripting and remote
+// capture (e.g. attaching a snapshot to a support bundle) instead of the
+// interactive TUI.
+func (c *Command) capture(ctx context.Context, clt services.ProcessHealth) error {
+	hosts, err := listAllProcessHealths(ctx, clt)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	w := os.Stdout
+	if c.output != "" {
+		f, err := os.Create(c.output)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return trace.Wrap(encodeProcessHealths(w, c.format, hosts))
+}
+
+// watchSnapshots writes a new capture every c.watchInterval until ctx is
+// done, so operators can tail fleet health the same way they'd tail a log
+// instead of re-running the command by hand.
+func (c *Command) watchSnapshots(ctx context.Context, clt services.ProcessHealth) error {
+	ticker := time.NewTicker(c.watchInterval)
+	defer ticker.Stop()
+
+	for {
+		hosts, err := listAllProcessHealths(ctx, clt)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := encodeProcessHealths(os.Stdout, c.format, hosts); err != nil {
+			return trace.Wrap(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// listAllProcessHealths pages through every ProcessHealth resource.
+func listAllProcessHealths(ctx context.Context, clt services.ProcessHealth) ([]*processhealthv1.ProcessHealth, error) {
+	var hosts []*processhealthv1.ProcessHealth
+	var nextToken string
+	for {
+		page, token, err := clt.ListProcessHealths(ctx, 0, nextToken)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		hosts = append(hosts, page...)
+		if token == "" {
+			break
+		}
+		nextToken = token
+	}
+	return hosts, nil
+}
+
+// encodeProcessHealths writes hosts to w as JSON or YAML depending on
+// format.
+func encodeProcessHealths(w *os.File, format string, hosts []*processhealthv1.ProcessHealth) error {
+	if format == formatYAML {
+		data, err := yaml.Marshal(hosts)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		_, err = w.Write(data)
+		return trace.Wrap(err)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return trace.Wrap(enc.Encode(hosts))
+}
+
+// captureProfile parses c.profile (profile[,duration]) and writes the
+// captured pprof profile to c.output, or a default filename derived from
+// the profile type if none was given.
+func (c *Command) captureProfile(ctx context.Context, clt *processhealth.Client) error {
+	profile, durationStr, _ := strings.Cut(c.profile, ",")
+	duration := defaultProfileDuration
+	if durationStr != "" {
+		var err error
+		duration, err = time.ParseDuration(durationStr)
+		if err != nil {
+			return trace.BadParameter("invalid --profile duration %q: %v", durationStr, err)
+		}
+	}
+
+	data, err := clt.CaptureProfile(ctx, c.profileHost, profile, duration)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	out := c.output
+	if out == "" {
+		out = profile + "-" + strconv.FormatInt(time.Now().Unix(), 10) + ".pprof"
+	}
+	return trace.Wrap(os.WriteFile(out, data, 0o600))
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/table"
@@ -27,49 +28,202 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/dustin/go-humanize"
 
+	processhealthv1 "github.com/gravitational/teleport/api/gen/proto/go/teleport/processhealth/v1"
+	"github.com/gravitational/teleport/api/types"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/trace"
 )
 
+// transitionRingCapacity bounds how many recent host state transitions are
+// kept in memory for the detail view, oldest overwritten first.
+const transitionRingCapacity = 50
+
+// transition records a single host moving from one computed status to
+// another, for display in the detail view.
+type transition struct {
+	hostID string
+	from   string
+	to     string
+	at     time.Time
+}
+
+// transitionRing is a fixed-capacity ring buffer of the most recent host
+// state transitions observed by the watcher this session.
+type transitionRing struct {
+	buf  []transition
+	next int
+	full bool
+}
+
+func newTransitionRing() *transitionRing {
+	return &transitionRing{buf: make([]transition, transitionRingCapacity)}
+}
+
+func (r *transitionRing) push(t transition) {
+	r.buf[r.next] = t
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// recent returns every entry currently in the ring, most recent first.
+func (r *transitionRing) recent() []transition {
+	count := r.next
+	if r.full {
+		count = len(r.buf)
+	}
+	out := make([]transition, 0, count)
+	i := r.next
+	for n := 0; n < count; n++ {
+		i--
+		if i < 0 {
+			i = len(r.buf) - 1
+		}
+		out = append(out, r.buf[i])
+	}
+	return out
+}
+
+// forHost returns the recent entries for hostID, most recent first.
+func (r *transitionRing) forHost(hostID string) []transition {
+	var out []transition
+	for _, t := range r.recent() {
+		if t.hostID == hostID {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
 type model struct {
-	ctx            context.Context
-	table          table.Model
-	tableType      string
-	clt            services.ProcessHealth
+	ctx       context.Context
+	table     table.Model
+	tableType string
+	clt       services.ProcessHealth
+	watcher   services.ProcessHealthWatcher
+
+	// hosts is kept in sync with the watcher: seeded with a snapshot at
+	// startup, then updated incrementally from each watch event instead of
+	// re-fetching the full list, so a slow or large fleet doesn't pay for a
+	// ListProcessHealths round trip on every change.
+	hosts map[string]*processhealthv1.ProcessHealth
+	// transitions records recent host status changes for the detail view.
+	transitions *transitionRing
+
 	selectedHostID string
+	// detail holds the pre-rendered text shown when tableType is "detail".
+	detail string
+}
+
+// processHealthWatcherStartedMsg carries the watcher back to the model once
+// the background subscription has been established.
+type processHealthWatcherStartedMsg struct {
+	watcher services.ProcessHealthWatcher
+}
+
+// processHealthUpdatedMsg carries a single create/update/delete event
+// observed by the background watcher, so the model can apply it to its
+// in-memory host map without a new fetch.
+type processHealthUpdatedMsg struct {
+	event services.ProcessHealthEvent
+}
+
+// startWatchingProcessHealths returns a tea.Cmd that opens a watcher and
+// reports it back to the model so it can be polled for subsequent updates.
+func startWatchingProcessHealths(ctx context.Context, clt services.ProcessHealth) tea.Cmd {
+	return func() tea.Msg {
+		watcher, err := clt.WatchProcessHealths(ctx)
+		if err != nil {
+			return nil
+		}
+		return processHealthWatcherStartedMsg{watcher: watcher}
+	}
+}
+
+// waitForProcessHealthUpdate returns a tea.Cmd that blocks until the watcher
+// observes the next change, or until it stops.
+func waitForProcessHealthUpdate(watcher services.ProcessHealthWatcher) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case event, ok := <-watcher.Events():
+			if !ok {
+				return nil
+			}
+			return processHealthUpdatedMsg{event: event}
+		case <-watcher.Done():
+			return nil
+		}
+	}
+}
+
+// hostsColumns are the columns of the top-level hosts table.
+var hostsColumns = []table.Column{
+	{Title: "HostID", Width: 12},
+	{Title: "Hostname", Width: 35},
+	{Title: "Version", Width: 10},
+	{Title: "Uptime", Width: 15},
+	{Title: "Services (ok/total)", Width: 20},
+	{Title: "_", Width: 2},
 }
 
 func initHostsTable(ctx context.Context, clt services.ProcessHealth) (tea.Model, error) {
-	rows, err := fetchHosts(ctx, clt)
+	hosts, err := fetchHosts(ctx, clt)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
-	columns := []table.Column{
-		{Title: "HostID", Width: 12},
-		{Title: "Hostname", Width: 35},
-		{Title: "Version", Width: 10},
-		{Title: "Uptime", Width: 15},
-		{Title: "Services (ok/total)", Width: 20},
-		{Title: "_", Width: 2},
-	}
-
 	return model{
-		ctx:       ctx,
-		table:     tableWithColumnsRows(columns, rows),
-		clt:       clt,
-		tableType: "hosts",
+		ctx:         ctx,
+		table:       buildHostsTable(hosts),
+		clt:         clt,
+		tableType:   "hosts",
+		hosts:       hosts,
+		transitions: newTransitionRing(),
 	}, nil
 }
 
-func fetchHosts(ctx context.Context, clt services.ProcessHealth) ([]table.Row, error) {
+// buildHostsTable builds a fresh hosts table.Model from hosts, for use both
+// at startup and whenever navigating back to the hosts view.
+func buildHostsTable(hosts map[string]*processhealthv1.ProcessHealth) table.Model {
+	return tableWithColumnsRows(hostsColumns, hostsTableRows(hosts))
+}
+
+// fetchHosts fetches a single page of ProcessHealth resources, starting from
+// the first, and returns it keyed by HostID, to seed the model's in-memory
+// map. Later pages are not fetched.
+func fetchHosts(ctx context.Context, clt services.ProcessHealth) (map[string]*processhealthv1.ProcessHealth, error) {
 	phs, _, err := clt.ListProcessHealths(ctx, 0, "")
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
-	var hosts []table.Row
+	hosts := make(map[string]*processhealthv1.ProcessHealth, len(phs))
 	for _, ph := range phs {
+		hosts[ph.GetMetadata().GetName()] = ph
+	}
+	return hosts, nil
+}
+
+// hostStatusLabel returns the glyph shown in the hosts table and used to
+// detect a status transition: "✅" if every unit is "ok", "⚠️" otherwise.
+func hostStatusLabel(ph *processhealthv1.ProcessHealth) string {
+	total := len(ph.GetStatus().GetUnitsByName())
+	ok := 0
+	for _, unit := range ph.GetStatus().GetUnitsByName() {
+		if unit.State == "ok" {
+			ok++
+		}
+	}
+	if total != ok {
+		return "⚠️"
+	}
+	return "✅"
+}
+
+func hostsTableRows(hosts map[string]*processhealthv1.ProcessHealth) []table.Row {
+	rows := make([]table.Row, 0, len(hosts))
+	for _, ph := range hosts {
 		uptime := ph.Status.SystemInfo.ProcessUptime
 		uptimeSince := time.Now().Add(-time.Second * time.Duration(uptime))
 
@@ -81,73 +235,96 @@ func fetchHosts(ctx context.Context, clt services.ProcessHealth) ([]table.Row, e
 			}
 		}
 
-		globalStatus := "✅"
-		if totalUnits != okUnits {
-			globalStatus = "⚠️"
-		}
-
-		hosts = append(hosts, table.Row{
+		rows = append(rows, table.Row{
 			ph.Metadata.Name,
 			ph.Status.SystemInfo.Hostname,
 			ph.Status.SystemInfo.TeleportVersion,
 			humanize.RelTime(uptimeSince, time.Now(), "ago", "from now"),
 			fmt.Sprintf("%d/%d", okUnits, totalUnits),
-			globalStatus,
+			hostStatusLabel(ph),
 		})
 	}
 
-	sort.Slice(hosts, func(i, j int) bool {
-		return hosts[i][1] < hosts[j][1] // Sort by hostname
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i][1] < rows[j][1] // Sort by hostname
 	})
 
-	return hosts, nil
+	return rows
 }
 
-func initUnitsTable(ctx context.Context, clt services.ProcessHealth, hostID string) (tea.Model, error) {
-	rows, err := fetchUnits(ctx, clt, hostID)
-	if err != nil {
-		return nil, trace.Wrap(err)
-	}
-
-	columns := []table.Column{
-		{Title: "Unit", Width: 30},
-		{Title: "State", Width: 5},
-	}
+// unitsColumns are the columns of the per-host units table.
+var unitsColumns = []table.Column{
+	{Title: "Unit", Width: 30},
+	{Title: "State", Width: 5},
+}
 
+func initUnitsTable(ph *processhealthv1.ProcessHealth) tea.Model {
 	return model{
-		ctx:            ctx,
-		table:          tableWithColumnsRows(columns, rows),
+		table:          buildUnitsTable(ph),
 		tableType:      "units",
-		selectedHostID: hostID,
-		clt:            clt,
-	}, nil
+		selectedHostID: ph.GetMetadata().GetName(),
+	}
 }
 
-func fetchUnits(ctx context.Context, clt services.ProcessHealth, hostID string) ([]table.Row, error) {
-	var rows []table.Row
-
-	phs, _, err := clt.ListProcessHealths(ctx, 0, "")
-	if err != nil {
-		return nil, trace.Wrap(err)
-	}
-	for _, ph := range phs {
-		if ph.Metadata.Name != hostID {
-			continue // Skip if the host ID does not match
-		}
+// buildUnitsTable builds a fresh units table.Model for ph.
+func buildUnitsTable(ph *processhealthv1.ProcessHealth) table.Model {
+	return tableWithColumnsRows(unitsColumns, unitsTableRows(ph))
+}
 
-		for unitName, unit := range ph.Status.UnitsByName {
-			rows = append(rows, table.Row{
-				unitName,
-				unit.State,
-			})
-		}
+func unitsTableRows(ph *processhealthv1.ProcessHealth) []table.Row {
+	var rows []table.Row
+	for unitName, unit := range ph.GetStatus().GetUnitsByName() {
+		rows = append(rows, table.Row{
+			unitName,
+			unit.State,
+		})
 	}
 
 	sort.Slice(rows, func(i, j int) bool {
 		return rows[i][0] < rows[j][0] // Sort by unit name
 	})
 
-	return rows, nil
+	return rows
+}
+
+// renderHostDetail renders the "press enter for detail view" screen for ph:
+// its full system info, every unit's state, and the transitions this
+// session has observed for it.
+func renderHostDetail(hostID string, ph *processhealthv1.ProcessHealth, transitions []transition) string {
+	var b strings.Builder
+
+	if ph == nil {
+		fmt.Fprintf(&b, "HostID %s is no longer reporting.\n\nesc: back\n", hostID)
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "HostID:    %s\n", ph.GetMetadata().GetName())
+	fmt.Fprintf(&b, "Hostname:  %s\n", ph.GetStatus().GetSystemInfo().GetHostname())
+	fmt.Fprintf(&b, "Version:   %s\n", ph.GetStatus().GetSystemInfo().GetTeleportVersion())
+	uptime := ph.GetStatus().GetSystemInfo().GetProcessUptime()
+	uptimeSince := time.Now().Add(-time.Second * time.Duration(uptime))
+	fmt.Fprintf(&b, "Uptime:    %s\n\n", humanize.RelTime(uptimeSince, time.Now(), "ago", "from now"))
+
+	b.WriteString("Units:\n")
+	unitNames := make([]string, 0, len(ph.GetStatus().GetUnitsByName()))
+	for name := range ph.GetStatus().GetUnitsByName() {
+		unitNames = append(unitNames, name)
+	}
+	sort.Strings(unitNames)
+	for _, name := range unitNames {
+		fmt.Fprintf(&b, "  %-30s %s\n", name, ph.GetStatus().GetUnitsByName()[name].State)
+	}
+
+	b.WriteString("\nRecent transitions this session:\n")
+	if len(transitions) == 0 {
+		b.WriteString("  (none recorded yet)\n")
+	}
+	for _, t := range transitions {
+		fmt.Fprintf(&b, "  %s  %s -> %s\n", t.at.Format(time.TimeOnly), t.from, t.to)
+	}
+
+	b.WriteString("\nesc: back\n")
+	return b.String()
 }
 
 func tableWithColumnsRows(columns []table.Column, rows []table.Row) table.Model {
@@ -174,59 +351,134 @@ func tableWithColumnsRows(columns []table.Column, rows []table.Row) table.Model
 	return t
 }
 
-func (m model) Init() tea.Cmd { return nil }
+// withWatcher carries the current watcher and in-memory state over into a
+// freshly-built model, since initUnitsTable doesn't know about either.
+func (m model) withWatcher(newM tea.Model) tea.Model {
+	next := newM.(model)
+	next.watcher = m.watcher
+	next.ctx = m.ctx
+	next.clt = m.clt
+	next.hosts = m.hosts
+	next.transitions = m.transitions
+	return next
+}
+
+func (m model) Init() tea.Cmd {
+	return startWatchingProcessHealths(m.ctx, m.clt)
+}
+
+// applyProcessHealthEvent updates m.hosts in place for ev and, if the
+// host's computed status changed, records it in m.transitions.
+func (m *model) applyProcessHealthEvent(ev services.ProcessHealthEvent) {
+	if m.hosts == nil {
+		m.hosts = make(map[string]*processhealthv1.ProcessHealth)
+	}
+	hostID := ev.Resource.GetMetadata().GetName()
+
+	if ev.Type == types.OpDelete {
+		delete(m.hosts, hostID)
+		return
+	}
+
+	var oldStatus string
+	if prev, ok := m.hosts[hostID]; ok {
+		oldStatus = hostStatusLabel(prev)
+	}
+	newStatus := hostStatusLabel(ev.Resource)
+	m.hosts[hostID] = ev.Resource
+
+	if oldStatus != "" && oldStatus != newStatus {
+		m.transitions.push(transition{hostID: hostID, from: oldStatus, to: newStatus, at: time.Now()})
+	}
+}
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
+	case processHealthWatcherStartedMsg:
+		m.watcher = msg.watcher
+		return m, waitForProcessHealthUpdate(m.watcher)
+
+	case processHealthUpdatedMsg:
+		m.applyProcessHealthEvent(msg.event)
+
+		switch m.tableType {
+		case "hosts":
+			m.table.SetRows(hostsTableRows(m.hosts))
+		case "units":
+			if ph, ok := m.hosts[m.selectedHostID]; ok {
+				m.table.SetRows(unitsTableRows(ph))
+			}
+		case "detail":
+			m.detail = renderHostDetail(m.selectedHostID, m.hosts[m.selectedHostID], m.transitions.forHost(m.selectedHostID))
+		}
+		return m, waitForProcessHealthUpdate(m.watcher)
+
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc":
+			if m.tableType == "detail" {
+				m.tableType = "hosts"
+				m.table = buildHostsTable(m.hosts)
+				return m, nil
+			}
 			if m.table.Focused() {
 				m.table.Blur()
 			} else {
 				m.table.Focus()
 			}
 		case "q", "ctrl+c":
+			if m.watcher != nil {
+				m.watcher.Close()
+			}
 			return m, tea.Quit
 
 		case "r":
-			if m.tableType == "units" {
-				newM, err := initUnitsTable(m.ctx, m.clt, m.selectedHostID)
-				if err != nil {
-					return m, tea.Quit
-				}
-				return newM, nil
-			}
-
-			newM, err := initHostsTable(m.ctx, m.clt)
+			hosts, err := fetchHosts(m.ctx, m.clt)
 			if err != nil {
 				return m, tea.Quit
 			}
+			m.hosts = hosts
+			switch m.tableType {
+			case "units":
+				if ph, ok := hosts[m.selectedHostID]; ok {
+					m.table = buildUnitsTable(ph)
+				}
+			case "detail":
+				m.detail = renderHostDetail(m.selectedHostID, hosts[m.selectedHostID], m.transitions.forHost(m.selectedHostID))
+			default:
+				m.table = buildHostsTable(hosts)
+			}
+			return m, nil
 
-			return newM, nil
+		case "enter":
+			if m.tableType != "hosts" || len(m.table.Rows()) == 0 {
+				return m, nil
+			}
+			hostID := m.table.SelectedRow()[0]
+			m.selectedHostID = hostID
+			m.tableType = "detail"
+			m.detail = renderHostDetail(hostID, m.hosts[hostID], m.transitions.forHost(hostID))
+			return m, nil
 
 		case "right":
-			if m.tableType == "units" {
-				return m, nil // No action if not in units view
+			if m.tableType != "hosts" {
+				return m, nil // No action if not in hosts view
 			}
-
-			newM, err := initUnitsTable(m.ctx, m.clt, m.table.SelectedRow()[0])
-			if err != nil {
-				return m, tea.Quit
+			hostID := m.table.SelectedRow()[0]
+			ph, ok := m.hosts[hostID]
+			if !ok {
+				return m, nil
 			}
+			return m.withWatcher(initUnitsTable(ph)), nil
 
-			return newM, nil
 		case "left":
 			if m.tableType == "hosts" {
-				return m, nil // No action if not in units view
-			}
-			newM, err := initHostsTable(m.ctx, m.clt)
-			if err != nil {
-				return m, tea.Quit
+				return m, nil // No action if not in units/detail view
 			}
-
-			return newM, nil
+			m.tableType = "hosts"
+			m.table = buildHostsTable(m.hosts)
+			return m, nil
 		}
 	}
 	m.table, cmd = m.table.Update(msg)
@@ -234,7 +486,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m model) View() string {
-	return lipgloss.NewStyle().
+	style := lipgloss.NewStyle().
 		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("240")).Render(m.table.View()) + "\n"
+		BorderForeground(lipgloss.Color("240"))
+
+	if m.tableType == "detail" {
+		return style.Render(m.detail) + "\n"
+	}
+	return style.Render(m.table.View()) + "\n"
 }